@@ -10,11 +10,17 @@ import (
 )
 
 var cli struct {
-	Create  cmd.CreateCmd  `cmd:"" help:"Create a new custom mode markdown file."`
-	List    cmd.ListCmd    `cmd:"" help:"List available custom modes."`
-	Export  cmd.ExportCmd  `cmd:"" help:"Export all modes to a .roomodes JSON file."`
-	Import  cmd.ImportCmd  `cmd:"" help:"Import modes from a .roomodes JSON file into the .roo/modes directory."`
-	Version cmd.VersionCmd `cmd:"" help:"Show version information."`
+	Create    cmd.CreateCmd    `cmd:"" help:"Create a new custom mode markdown file."`
+	List      cmd.ListCmd      `cmd:"" help:"List available custom modes."`
+	Export    cmd.ExportCmd    `cmd:"" help:"Export all modes to a .roomodes JSON or YAML file."`
+	Import    cmd.ImportCmd    `cmd:"" help:"Import modes from a .roomodes JSON or YAML file into the .roo/modes directory."`
+	Diff      cmd.DiffCmd      `cmd:"" help:"Compare modes in .roo/modes with a .roomodes file, or two .roomodes files."`
+	Sync      cmd.SyncCmd      `cmd:"" help:"Sync changes between .roo/modes and .roomodes in both directions."`
+	Validate  cmd.ValidateCmd  `cmd:"" help:"Validate mode files and report all problems."`
+	Lint      cmd.LintCmd      `cmd:"" help:"Run style checks on mode files."`
+	RegexTest cmd.RegexTestCmd `cmd:"" help:"Show which groups of a mode allow the given paths."`
+	Schema    cmd.SchemaCmd    `cmd:"" help:"Print the JSON Schema for mode frontmatter or .roomodes files."`
+	Version   cmd.VersionCmd   `cmd:"" help:"Show version information."`
 }
 
 func main() {
@@ -10,6 +10,7 @@ import (
 	"github.com/upamune/roomode/internal/config"
 	"github.com/upamune/roomode/internal/editor"
 	"github.com/upamune/roomode/internal/fileutil"
+	"github.com/upamune/roomode/internal/mode"
 )
 
 // CreateCmd is a command to create a new custom mode Markdown file
@@ -21,8 +22,8 @@ type CreateCmd struct {
 // Run executes the CreateCmd
 func (cmd *CreateCmd) Run() error {
 	// 1. Validate slug
-	if !fileutil.IsValidFilename(cmd.Slug) {
-		return fmt.Errorf("invalid slug: %s (contains invalid characters)", cmd.Slug)
+	if !mode.IsValidSlug(cmd.Slug) {
+		return fmt.Errorf("invalid slug: %s (must contain only letters, numbers and dashes)", cmd.Slug)
 	}
 
 	// 2. Load configuration
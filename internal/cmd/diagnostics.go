@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/upamune/roomode/internal/fileutil"
+	"github.com/upamune/roomode/internal/lint"
+	"github.com/upamune/roomode/internal/mode"
+	"github.com/upamune/roomode/internal/report"
+)
+
+// writeDiagnostics prints diagnostics to stdout in the given format
+// File paths are made relative to the working directory
+func writeDiagnostics(format string, diags mode.Diagnostics) error {
+	relative := make(mode.Diagnostics, len(diags))
+	for i, d := range diags {
+		d.File = displayPath(d.File)
+		relative[i] = d
+	}
+
+	rules := make([]report.Rule, 0, len(lint.Rules))
+	for _, rule := range lint.Rules {
+		rules = append(rules, report.Rule{ID: rule.ID, Description: rule.Description})
+	}
+
+	return report.Write(os.Stdout, format, relative, report.Options{
+		ToolVersion: Version,
+		Rules:       rules,
+	})
+}
+
+// collectModeFiles expands the given paths into a list of mode files
+// Directories are expanded to the Markdown files they contain
+// If no paths are given, all files in the modes directory are returned
+func collectModeFiles(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		files, err := fileutil.ListModeFiles()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list mode files: %w", err)
+		}
+		return files, nil
+	}
+
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to access %s: %w", path, err)
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		dirFiles, err := fileutil.ListMarkdownFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, dirFiles...)
+	}
+
+	return files, nil
+}
+
+// displayPath returns path relative to the working directory when possible
+func displayPath(path string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+
+	rel, err := filepath.Rel(wd, path)
+	if err != nil {
+		return path
+	}
+
+	return rel
+}
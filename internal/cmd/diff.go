@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/upamune/roomode/internal/diff"
+	"github.com/upamune/roomode/internal/fileutil"
+	"github.com/upamune/roomode/internal/mode"
+)
+
+// DiffCmd is a command to compare the modes of .roo/modes and .roomodes files field by field
+type DiffCmd struct {
+	Files  []string `arg:"" optional:"" help:"One .roomodes JSON or YAML file to compare .roo/modes with (default: .roomodes), or two .roomodes files to compare with each other."`
+	Format string   `short:"f" enum:"text,markdown" default:"text" help:"Output format (text, markdown)."`
+}
+
+// Run executes the DiffCmd
+func (cmd *DiffCmd) Run() error {
+	// 1. Load both sides: the first file and either the second file or the mode files
+	var oldName, newName string
+	var oldModes, newModes []*mode.Config
+	var err error
+
+	switch len(cmd.Files) {
+	case 0, 1:
+		oldName = ".roomodes"
+		if len(cmd.Files) == 1 {
+			oldName = cmd.Files[0]
+		}
+		oldModes, err = loadRoomodesModes(oldName)
+		if err != nil {
+			return err
+		}
+
+		// The mode files are rendered as they would be exported
+		modesDir, err := fileutil.GetModesDir()
+		if err != nil {
+			return err
+		}
+		newName = displayPath(modesDir)
+		newModes, err = loadSourceModes()
+		if err != nil {
+			return err
+		}
+	case 2:
+		oldName, newName = cmd.Files[0], cmd.Files[1]
+		oldModes, err = loadRoomodesModes(oldName)
+		if err != nil {
+			return err
+		}
+		newModes, err = loadRoomodesModes(newName)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("expected at most 2 files to compare, got %d", len(cmd.Files))
+	}
+
+	// 2. Compare the modes and print the changes
+	changes := diff.Modes(oldModes, newModes)
+	if len(changes) == 0 {
+		log.Info(fmt.Sprintf("No differences between %s and %s", oldName, newName))
+		return nil
+	}
+
+	write := diff.WriteText
+	if cmd.Format == "markdown" {
+		write = diff.WriteMarkdown
+	}
+	if err := write(os.Stdout, changes, oldName, newName); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("%d modes differ between %s and %s", len(changes), oldName, newName)
+}
+
+// loadSourceModes loads every mode file in .roo/modes with its templates rendered
+func loadSourceModes() ([]*mode.Config, error) {
+	files, err := fileutil.ListModeFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mode files: %w", err)
+	}
+
+	project, err := currentProject()
+	if err != nil {
+		return nil, err
+	}
+
+	modes := make([]*mode.Config, 0, len(files))
+	for _, file := range files {
+		modeConfig, err := mode.LoadModeFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse mode file %s: %w", file, err)
+		}
+
+		rendered, err := mode.RenderTemplates(modeConfig, project)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render templates of mode %s: %w", modeConfig.Slug, err)
+		}
+		modes = append(modes, rendered)
+	}
+
+	return modes, nil
+}
+
+// loadRoomodesModes loads the modes of a .roomodes file
+func loadRoomodesModes(path string) ([]*mode.Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	modes, err := parseRoomodesModes(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return modes, nil
+}
+
+// parseRoomodesModes parses .roomodes content into mode configs
+func parseRoomodesModes(data []byte) ([]*mode.Config, error) {
+	roomodesFile, err := parseRoomodes(data)
+	if err != nil {
+		return nil, err
+	}
+	return roomodesFile.modeConfigs()
+}
+
+// modeConfigs converts the modes of a .roomodes file into mode configs
+func (f RoomodesFile) modeConfigs() ([]*mode.Config, error) {
+	modes := make([]*mode.Config, 0, len(f.CustomModes))
+	for _, m := range f.CustomModes {
+		modeConfig, err := m.toModeConfig()
+		if err != nil {
+			return nil, fmt.Errorf("invalid mode %s: %w", m.Slug, err)
+		}
+		modes = append(modes, modeConfig)
+	}
+
+	return modes, nil
+}
@@ -1,24 +1,86 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/log"
+	"gopkg.in/yaml.v3"
 
+	"github.com/upamune/roomode/internal/diff"
 	"github.com/upamune/roomode/internal/fileutil"
 	"github.com/upamune/roomode/internal/mode"
 )
 
-// ExportCmd is a command to export all modes to a .roomodes JSON file
+// ExportCmd is a command to export all modes to the custom modes file of an export target
 type ExportCmd struct {
-	OutputFile *string `arg:"" optional:"" help:"Output file path (default: .roomodes)."`
+	OutputFile *string `arg:"" optional:"" help:"Output file path (default: the target's modes file, e.g. .roomodes)."`
+	Single     string  `help:"Export only the mode with this slug, with its rule files, in RooCode's single-mode share format. Written to standard output unless an output file is given." placeholder:"SLUG"`
+	WithRules  bool    `help:"Bundle the rule files of each mode from .roo/rules-{slug}/ into the export." default:"false"`
+	Format     string  `help:"Output format (${enum}). auto keeps the format of the existing output file, or uses JSON." enum:"auto,json,yaml" default:"auto"`
+	Merge      bool    `help:"Keep the modes of the existing output file that have no mode file in .roo/modes, in their order. Asked when run in a terminal." default:"false"`
+	Check      bool    `help:"Check that the output file is up to date instead of writing it. Fails with a diff if it differs, or if any mode is invalid." default:"false"`
+	Target     string  `help:"Tool to export the modes for (${enum}). Sets the default output file and the groups and fields allowed." enum:"roo,kilocode" default:"roo"`
+}
+
+// ExportedMode represents a mode as written to the .roomodes file
+type ExportedMode struct {
+	Slug               string        `yaml:"slug" json:"slug"`
+	Name               string        `yaml:"name" json:"name"`
+	Groups             []interface{} `yaml:"groups" json:"groups"`
+	CustomInstructions *string       `yaml:"customInstructions,omitempty" json:"customInstructions,omitempty"`
+	RoleDefinition     string        `yaml:"roleDefinition" json:"roleDefinition"`
+	WhenToUse          string        `yaml:"whenToUse,omitempty" json:"whenToUse,omitempty"`
+	Description        string        `yaml:"description,omitempty" json:"description,omitempty"`
+	Source             string        `yaml:"source,omitempty" json:"source,omitempty"`
+	RulesFiles         []RulesFile   `yaml:"rulesFiles,omitempty" json:"rulesFiles,omitempty"`
+
+	raw *yaml.Node // Set for modes kept from an existing file, which are written as they were read
+}
+
+// MarshalJSON encodes the mode, or the mode as it was read if it was kept from an existing file
+func (m ExportedMode) MarshalJSON() ([]byte, error) {
+	if m.raw != nil {
+		return nodeToJSON(m.raw)
+	}
+	type plain ExportedMode
+	return json.Marshal(plain(m))
+}
+
+// MarshalYAML encodes the mode, or the mode as it was read if it was kept from an existing file
+func (m ExportedMode) MarshalYAML() (interface{}, error) {
+	if m.raw != nil {
+		return m.raw, nil
+	}
+	type plain ExportedMode
+	return plain(m), nil
+}
+
+// ExportData represents the structure of the exported .roomodes file
+type ExportData struct {
+	CustomModes []ExportedMode `yaml:"customModes" json:"customModes"`
 }
 
 // Run executes the ExportCmd
 func (cmd *ExportCmd) Run() error {
+	target, err := lookupExportTarget(cmd.Target)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Single != "" {
+		if cmd.Check {
+			return fmt.Errorf("--check can't be used with --single")
+		}
+		return cmd.exportSingle(target)
+	}
+
 	// 1. Get list of mode files
 	files, err := fileutil.ListModeFiles()
 	if err != nil {
@@ -26,20 +88,27 @@ func (cmd *ExportCmd) Run() error {
 	}
 
 	// 2. Handle case when no mode files are found
-	if len(files) == 0 {
+	// A check still compares the empty export with the output file
+	if len(files) == 0 && !cmd.Check {
 		log.Info("No custom modes found to export")
 		return nil
 	}
 
 	// 3. Parse and validate each mode file
-	log.Info(fmt.Sprintf("Exporting %d custom modes:", len(files)))
+	if cmd.Check {
+		log.Info(fmt.Sprintf("Checking export of %d custom modes:", len(files)))
+	} else {
+		log.Info(fmt.Sprintf("Exporting %d custom modes:", len(files)))
+	}
 
 	var validModes []*mode.Config
 	invalidCount := 0
+	sourceSlugs := make(map[string]bool, len(files))
 
 	for _, file := range files {
+		sourceSlugs[strings.TrimSuffix(filepath.Base(file), ".md")] = true
 
-		modeConfig, err := mode.ParseModeFile(file)
+		modeConfig, err := mode.LoadModeFile(file)
 		if err != nil {
 			log.Error("Failed to parse mode file", "file", file, "error", err)
 			invalidCount++
@@ -53,82 +122,440 @@ func (cmd *ExportCmd) Run() error {
 			continue
 		}
 
+		err = target.Validate(modeConfig)
+		if err != nil {
+			log.Error("Mode not supported by target", "file", file, "target", target.Name(), "error", err)
+			invalidCount++
+			continue
+		}
+
 		validModes = append(validModes, modeConfig)
 	}
 
 	// 4. Determine output file path
-	outputPath := ".roomodes"
+	outputPath := target.DefaultPath()
 	if cmd.OutputFile != nil {
 		outputPath = *cmd.OutputFile
 	}
 
-	// 5. Create data structure for export
-	type ExportedMode struct {
-		Slug               string        `json:"slug"`
-		Name               string        `json:"name"`
-		Groups             []interface{} `json:"groups"`
-		CustomInstructions *string       `json:"customInstructions,omitempty"`
-		RoleDefinition     string        `json:"roleDefinition"`
-		Source             string        `json:"source,omitempty"`
+	format := cmd.Format
+	if format == "auto" {
+		format = existingRoomodesFormat(outputPath)
 	}
 
-	type ExportData struct {
-		CustomModes []ExportedMode `json:"customModes"`
+	project, err := currentProject()
+	if err != nil {
+		return err
 	}
 
+	// 5. Create data structure for export
 	modes := make([]ExportedMode, 0, len(validModes))
 	for _, m := range validModes {
-		// Convert ParsedGroupEntry to the expected format for TypeScript schema
-		formattedGroups := make([]interface{}, 0, len(m.GroupsParsed))
-		for _, g := range m.GroupsParsed {
-			if g.Options == nil {
-				// Simple string format
-				formattedGroups = append(formattedGroups, g.Name)
-			} else {
-				// Array format [string, options]
-				formattedGroups = append(formattedGroups, []interface{}{
-					g.Name,
-					g.Options,
-				})
-			}
+		exported, err := newExportedMode(m, project, cmd.WithRules)
+		if err != nil {
+			return err
 		}
+		modes = append(modes, target.Filter(exported))
+	}
 
-		modes = append(modes, ExportedMode{
-			Slug:               m.Slug,
-			Name:               m.Name,
-			Groups:             formattedGroups,
-			CustomInstructions: m.CustomInstructions,
-			RoleDefinition:     m.RoleDefinition,
-			Source:             m.Source,
-		})
+	// 6. Keep the modes of the existing file that have no mode file, if requested
+	modes, err = cmd.mergeExisting(outputPath, modes, sourceSlugs)
+	if err != nil {
+		return err
 	}
 
-	// 6. Create the final export data structure and convert to JSON
+	// 7. Create the final export data structure and encode it
 	exportData := ExportData{
 		CustomModes: modes,
 	}
-	jsonData, err := json.MarshalIndent(exportData, "", "  ")
+	outputData, err := marshalRoomodes(exportData, format)
 	if err != nil {
-		return fmt.Errorf("failed to marshal JSON: %w", err)
+		return err
 	}
 
-	// 7. Write to file
-	outputDir := filepath.Dir(outputPath)
-	if outputDir != "." {
-		if err := os.MkdirAll(outputDir, 0755); err != nil {
-			return fmt.Errorf("failed to create output directory: %w", err)
+	// 8. In check mode, compare with the file instead of writing it
+	// Without mode files, export doesn't write a file, so a missing file is up to date
+	if cmd.Check {
+		if len(files) == 0 && !fileutil.FileExists(outputPath) {
+			log.Info(fmt.Sprintf("Export check passed: no custom modes and no %s", outputPath))
+			return nil
 		}
+		return checkOutputFile(outputPath, outputData, invalidCount)
 	}
 
-	if err := os.WriteFile(outputPath, jsonData, 0644); err != nil {
-		return fmt.Errorf("failed to write output file: %w", err)
+	// 9. Write to file
+	if err := writeOutputFile(outputPath, outputData); err != nil {
+		return err
 	}
 
-	// 8. Display results
+	// 10. Display results
 	log.Info(fmt.Sprintf("Export complete: %d modes exported to %s", len(validModes), outputPath))
+	if kept := len(modes) - len(validModes); kept > 0 {
+		log.Info(fmt.Sprintf("%d existing modes without an exported mode file were kept", kept))
+	}
 	if invalidCount > 0 {
 		log.Warn(fmt.Sprintf("%d invalid modes were skipped", invalidCount))
 	}
 
 	return nil
 }
+
+// mergeExisting returns the exported modes merged into the modes of the existing output file
+// Modes of the existing file without a mode file are always reported, and kept in their order,
+// as they were read, when --merge is set or confirmed. Exported modes replace the modes with the
+// same slug, and new modes are added at the end. Otherwise the exported modes are returned as
+// they are.
+func (cmd *ExportCmd) mergeExisting(path string, modes []ExportedMode, sourceSlugs map[string]bool) ([]ExportedMode, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return modes, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output file: %w", err)
+	}
+
+	// A file that can't be parsed, e.g. with merge conflict markers, is only regenerated
+	// when its modes weren't asked to be kept
+	existing, err := parseRoomodes(data)
+	if err != nil {
+		if cmd.Merge {
+			return nil, fmt.Errorf("failed to parse existing %s, fix it to merge its modes: %w", path, err)
+		}
+		log.Warn(fmt.Sprintf("Failed to parse existing %s, no modes are kept from it", path), "error", err)
+		return modes, nil
+	}
+
+	var orphans []string
+	for _, m := range existing.CustomModes {
+		if !sourceSlugs[m.Slug] {
+			orphans = append(orphans, m.Slug)
+		}
+	}
+	if len(orphans) == 0 {
+		return modes, nil
+	}
+
+	log.Warn(fmt.Sprintf("%d modes in %s have no mode file in .roo/modes: %s", len(orphans), path, strings.Join(orphans, ", ")))
+
+	// A check only reports the modes, the comparison shows whether they are removed
+	if cmd.Check && !cmd.Merge {
+		return modes, nil
+	}
+
+	merge := cmd.Merge
+	if !merge && isInteractive() {
+		form := huh.NewForm(
+			huh.NewGroup(
+				huh.NewConfirm().
+					Title(fmt.Sprintf("Keep %d modes without a mode file in %s?", len(orphans), path)).
+					Value(&merge),
+			),
+		)
+		if err := form.Run(); err != nil {
+			return nil, fmt.Errorf("form error: %w", err)
+		}
+	}
+	if !merge {
+		log.Warn(fmt.Sprintf("Removing %d modes from %s (use --merge to keep them)", len(orphans), path))
+		return modes, nil
+	}
+
+	exported := make(map[string]int, len(modes))
+	for i, m := range modes {
+		exported[m.Slug] = i
+	}
+
+	// Modes with an invalid mode file keep their existing entry
+	merged := make([]ExportedMode, 0, len(modes)+len(orphans))
+	added := make(map[string]bool, len(modes))
+	for _, m := range existing.CustomModes {
+		if i, ok := exported[m.Slug]; ok {
+			merged = append(merged, modes[i])
+			added[m.Slug] = true
+			continue
+		}
+		merged = append(merged, m.toExportedMode())
+	}
+	for _, m := range modes {
+		if !added[m.Slug] {
+			merged = append(merged, m)
+		}
+	}
+
+	return merged, nil
+}
+
+// exportSingle exports one mode with its rule files, as the RooCode UI does when sharing a mode
+func (cmd *ExportCmd) exportSingle(target ExportTarget) error {
+	filePath, err := fileutil.GetModeFilePath(cmd.Single)
+	if err != nil {
+		return err
+	}
+
+	if !fileutil.FileExists(filePath) {
+		return fmt.Errorf("mode not found: %s", cmd.Single)
+	}
+
+	modeConfig, err := mode.LoadModeFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to parse mode file: %w", err)
+	}
+
+	if err := mode.ValidateMode(modeConfig); err != nil {
+		return fmt.Errorf("invalid mode file: %w", err)
+	}
+
+	if err := target.Validate(modeConfig); err != nil {
+		return fmt.Errorf("mode not supported by %s: %w", target.Name(), err)
+	}
+
+	project, err := currentProject()
+	if err != nil {
+		return err
+	}
+
+	exported, err := newExportedMode(modeConfig, project, true)
+	if err != nil {
+		return err
+	}
+
+	// The RooCode UI shares modes as YAML
+	format := cmd.Format
+	if format == "auto" {
+		format = RoomodesFormatYAML
+	}
+
+	exported = target.Filter(exported)
+
+	outputData, err := marshalRoomodes(ExportData{CustomModes: []ExportedMode{exported}}, format)
+	if err != nil {
+		return err
+	}
+
+	if cmd.OutputFile == nil {
+		_, err := os.Stdout.Write(outputData)
+		return err
+	}
+
+	if err := writeOutputFile(*cmd.OutputFile, outputData); err != nil {
+		return err
+	}
+
+	log.Info("Export complete", "slug", cmd.Single, "file", *cmd.OutputFile, "rulesFiles", len(exported.RulesFiles))
+	return nil
+}
+
+// newExportedMode converts a parsed mode to its .roomodes representation
+// Templates in the mode are rendered for project, and the files of the mode's rules
+// directory are bundled when withRules is set
+func newExportedMode(m *mode.Config, project mode.ProjectInfo, withRules bool) (ExportedMode, error) {
+	rendered, err := mode.RenderTemplates(m, project)
+	if err != nil {
+		return ExportedMode{}, fmt.Errorf("failed to render templates of mode %s: %w", m.Slug, err)
+	}
+	m = rendered
+
+	// Convert ParsedGroupEntry to the expected format for TypeScript schema
+	formattedGroups := make([]interface{}, 0, len(m.GroupsParsed))
+	for _, g := range m.GroupsParsed {
+		if g.Options == nil {
+			// Simple string format
+			formattedGroups = append(formattedGroups, g.Name)
+		} else {
+			// Array format [string, options]
+			formattedGroups = append(formattedGroups, []interface{}{
+				g.Name,
+				g.Options,
+			})
+		}
+	}
+
+	// Bundle the mode's rules directory if requested
+	var rulesFiles []RulesFile
+	if withRules {
+		rulesFiles, err = readRulesFiles(m.Slug)
+		if err != nil {
+			return ExportedMode{}, fmt.Errorf("failed to bundle rules of mode %s: %w", m.Slug, err)
+		}
+	}
+
+	return ExportedMode{
+		Slug:               m.Slug,
+		Name:               m.Name,
+		Groups:             formattedGroups,
+		CustomInstructions: m.CustomInstructions,
+		RoleDefinition:     m.RoleDefinition,
+		WhenToUse:          m.WhenToUse,
+		Description:        m.Description,
+		Source:             m.Source,
+		RulesFiles:         rulesFiles,
+	}, nil
+}
+
+// currentProject describes the project in the working directory, for rendering templates
+func currentProject() (mode.ProjectInfo, error) {
+	root, err := os.Getwd()
+	if err != nil {
+		return mode.ProjectInfo{}, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	return mode.ProjectInfo{Name: filepath.Base(root), Root: root}, nil
+}
+
+// checkOutputFile reports whether the file at path holds the rendered export data
+// Changed modes are printed field by field. The check also fails when invalid modes were
+// left out of the export.
+func checkOutputFile(path string, data []byte, invalidCount int) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read output file: %w", err)
+	}
+
+	var problems []string
+	switch {
+	case os.IsNotExist(err):
+		problems = append(problems, fmt.Sprintf("%s does not exist", path))
+	case !bytes.Equal(existing, data):
+		changes, err := compareRoomodes(existing, data)
+		if err != nil {
+			return fmt.Errorf("failed to compare with %s: %w", path, err)
+		}
+		if len(changes) == 0 {
+			problems = append(problems, fmt.Sprintf("%s is out of date (only formatting or the order of modes differs)", path))
+			break
+		}
+		if err := diff.WriteText(os.Stdout, changes, path, "export"); err != nil {
+			return err
+		}
+		problems = append(problems, fmt.Sprintf("%s is out of date", path))
+	}
+	if invalidCount > 0 {
+		problems = append(problems, fmt.Sprintf("%d invalid modes were skipped", invalidCount))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("export check failed: %s", strings.Join(problems, ", "))
+	}
+
+	log.Info(fmt.Sprintf("Export check passed: %s is up to date", path))
+	return nil
+}
+
+// compareRoomodes compares the modes of two .roomodes documents, including their rule files
+func compareRoomodes(old, new []byte) ([]diff.ModeChange, error) {
+	oldFile, err := parseRoomodes(old)
+	if err != nil {
+		return nil, err
+	}
+	newFile, err := parseRoomodes(new)
+	if err != nil {
+		return nil, err
+	}
+
+	oldModes, err := oldFile.modeConfigs()
+	if err != nil {
+		return nil, err
+	}
+	newModes, err := newFile.modeConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	changes := diff.Modes(oldModes, newModes)
+	return addRulesFilesChanges(changes, oldFile.CustomModes, newFile.CustomModes), nil
+}
+
+// addRulesFilesChanges adds the changes to the rule files of modes on both sides to changes
+// Each rule file is compared as a text field named after its path, e.g.
+// "rulesFiles[rules-test/01-style.md]".
+func addRulesFilesChanges(changes []diff.ModeChange, old, new []ImportedMode) []diff.ModeChange {
+	newRules := make(map[string]map[string]string, len(new))
+	for _, m := range new {
+		newRules[m.Slug] = rulesFileContents(m)
+	}
+
+	rulesChanges := make(map[string][]diff.FieldChange)
+	for _, m := range old {
+		newFiles, ok := newRules[m.Slug]
+		if !ok {
+			continue
+		}
+		oldFiles := rulesFileContents(m)
+
+		paths := make([]string, 0, len(oldFiles)+len(newFiles))
+		for path := range oldFiles {
+			paths = append(paths, path)
+		}
+		for path := range newFiles {
+			if _, ok := oldFiles[path]; !ok {
+				paths = append(paths, path)
+			}
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			oldContent, inOld := oldFiles[path]
+			newContent, inNew := newFiles[path]
+			if inOld != inNew || oldContent != newContent {
+				rulesChanges[m.Slug] = append(rulesChanges[m.Slug], diff.FieldChange{
+					Field: "rulesFiles[" + path + "]",
+					Old:   oldContent,
+					New:   newContent,
+					Text:  true,
+				})
+			}
+		}
+	}
+	if len(rulesChanges) == 0 {
+		return changes
+	}
+
+	// Changes keep the order of diff.Modes: modes of old, then added modes
+	bySlug := make(map[string]diff.ModeChange, len(changes))
+	for _, c := range changes {
+		bySlug[c.Slug] = c
+	}
+	result := make([]diff.ModeChange, 0, len(changes)+len(rulesChanges))
+	for _, m := range old {
+		c, ok := bySlug[m.Slug]
+		fields := rulesChanges[m.Slug]
+		if !ok && len(fields) == 0 {
+			continue
+		}
+		if !ok {
+			c = diff.ModeChange{Slug: m.Slug, Kind: diff.Changed}
+		}
+		c.Fields = append(c.Fields, fields...)
+		result = append(result, c)
+	}
+	for _, c := range changes {
+		if c.Kind == diff.Added {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// rulesFileContents returns the content of each rule file of a mode by path
+func rulesFileContents(m ImportedMode) map[string]string {
+	files := make(map[string]string, len(m.RulesFiles))
+	for _, f := range m.RulesFiles {
+		files[f.RelativePath] = f.Content
+	}
+	return files
+}
+
+// writeOutputFile writes data to path, creating the parent directory if needed
+func writeOutputFile(path string, data []byte) error {
+	outputDir := filepath.Dir(path)
+	if outputDir != "." {
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	return nil
+}
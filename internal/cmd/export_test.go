@@ -0,0 +1,348 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/log"
+	"github.com/upamune/roomode/internal/diff"
+	"github.com/upamune/roomode/internal/fileutil"
+)
+
+func TestExportCheck(t *testing.T) {
+	const testMode = "---\nname: Test\nroleDefinition: You are Roo\ngroups: [read]\n---\n"
+	const testRoomodes = `{"customModes": [{"slug": "test", "name": "Test", "groups": ["read"], "roleDefinition": "You are Roo"}]}`
+
+	tests := []struct {
+		name    string
+		cmd     ExportCmd
+		files   map[string]string
+		export  bool // Export before checking
+		wantErr string
+	}{
+		{
+			name:   "up to date",
+			files:  map[string]string{".roo/modes/test.md": testMode},
+			export: true,
+		},
+		{
+			name:    "missing output file",
+			files:   map[string]string{".roo/modes/test.md": testMode},
+			wantErr: ".roomodes does not exist",
+		},
+		{
+			name:    "only formatting differs",
+			files:   map[string]string{".roo/modes/test.md": testMode, ".roomodes": testRoomodes},
+			wantErr: ".roomodes is out of date (only formatting or the order of modes differs)",
+		},
+		{
+			name:    "no mode files",
+			files:   map[string]string{".roomodes": testRoomodes},
+			wantErr: ".roomodes is out of date",
+		},
+		{
+			name:  "no mode files and no output file",
+			files: map[string]string{},
+		},
+		{
+			name:    "with --single",
+			cmd:     ExportCmd{Single: "test"},
+			files:   map[string]string{".roo/modes/test.md": testMode},
+			wantErr: "--check can't be used with --single",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupWorkspace(t, tt.files)
+
+			if tt.export {
+				if err := (&ExportCmd{Format: "auto", Target: TargetRoo}).Run(); err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+			}
+
+			cmd := tt.cmd
+			cmd.Check, cmd.Format, cmd.Target = true, "auto", TargetRoo
+			err := cmd.Run()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Run() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Run() error = %v, want an error containing %q", err, tt.wantErr)
+			}
+			if tt.cmd.Single != "" && fileutil.FileExists(".roomodes") {
+				t.Error("--check --single wrote .roomodes")
+			}
+		})
+	}
+}
+
+// captureLog returns a buffer that receives the log output until the test ends
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func TestExportMerge(t *testing.T) {
+	const testMode = "---\nname: Test\nroleDefinition: You are Roo\ngroups: [read]\n---\n"
+	const conflictedRoomodes = `{"customModes": [
+<<<<<<< HEAD
+  {"slug": "ui", "name": "UI"}
+=======
+  {"slug": "ui", "name": "User Interface"}
+>>>>>>> ui
+]}`
+	const regenerated = `{
+  "customModes": [
+    {
+      "slug": "test",
+      "name": "Test",
+      "groups": [
+        "read"
+      ],
+      "roleDefinition": "You are Roo"
+    }
+  ]
+}`
+
+	interactive := isInteractive
+	isInteractive = func() bool { return false }
+	t.Cleanup(func() { isInteractive = interactive })
+
+	tests := []struct {
+		name     string
+		cmd      ExportCmd
+		roomodes string
+		want     string
+		wantLog  string
+		wantErr  string
+	}{
+		{
+			name: "keeps unknown fields of JSON modes",
+			cmd:  ExportCmd{Merge: true},
+			roomodes: `{"customModes": [
+  {"slug": "ui", "name": "UI", "custom": {"b": 1, "a": [true, null, 1.5]}, "roleDefinition": "You are Roo", "groups": ["read"]}
+]}`,
+			want: `{
+  "customModes": [
+    {
+      "slug": "ui",
+      "name": "UI",
+      "custom": {
+        "b": 1,
+        "a": [
+          true,
+          null,
+          1.5
+        ]
+      },
+      "roleDefinition": "You are Roo",
+      "groups": [
+        "read"
+      ]
+    },
+    {
+      "slug": "test",
+      "name": "Test",
+      "groups": [
+        "read"
+      ],
+      "roleDefinition": "You are Roo"
+    }
+  ]
+}`,
+		},
+		{
+			name: "keeps unknown fields of YAML modes",
+			cmd:  ExportCmd{Merge: true},
+			roomodes: `customModes:
+  - slug: ui
+    name: UI
+    custom: {b: 1, a: x}
+    roleDefinition: You are Roo
+    groups: [read]
+`,
+			want: `customModes:
+  - slug: ui
+    name: UI
+    custom: {b: 1, a: x}
+    roleDefinition: You are Roo
+    groups: [read]
+  - slug: test
+    name: Test
+    groups:
+      - read
+    roleDefinition: You are Roo
+`,
+		},
+		{
+			name:     "keeps a file that can't be parsed with --merge",
+			cmd:      ExportCmd{Merge: true},
+			roomodes: conflictedRoomodes,
+			want:     conflictedRoomodes,
+			wantErr:  "failed to parse existing .roomodes, fix it to merge its modes",
+		},
+		{
+			name:     "regenerates a file that can't be parsed without --merge",
+			roomodes: conflictedRoomodes,
+			want:     regenerated,
+			wantLog:  "Failed to parse existing .roomodes, no modes are kept from it",
+		},
+		{
+			name:     "reports removed modes without --merge",
+			roomodes: `{"customModes": [{"slug": "ui", "name": "UI", "roleDefinition": "You are Roo", "groups": ["read"]}]}`,
+			want:     regenerated,
+			wantLog:  "Removing 1 modes from .roomodes (use --merge to keep them)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupWorkspace(t, map[string]string{".roo/modes/test.md": testMode, ".roomodes": tt.roomodes})
+			logs := captureLog(t)
+
+			cmd := tt.cmd
+			cmd.Format, cmd.Target = "auto", TargetRoo
+			err := cmd.Run()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Run() error = %v, want an error containing %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if got := readFile(t, ".roomodes"); got != tt.want {
+				t.Errorf(".roomodes = %s, want %s", got, tt.want)
+			}
+			if tt.wantLog != "" && !strings.Contains(logs.String(), tt.wantLog) {
+				t.Errorf("log = %q, want it to contain %q", logs.String(), tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestExportCheckDoesNotRemove(t *testing.T) {
+	interactive := isInteractive
+	isInteractive = func() bool { return true }
+	t.Cleanup(func() { isInteractive = interactive })
+
+	setupWorkspace(t, map[string]string{
+		".roo/modes/test.md": "---\nname: Test\nroleDefinition: You are Roo\ngroups: [read]\n---\n",
+		".roomodes":          `{"customModes": [{"slug": "ui", "name": "UI", "roleDefinition": "You are Roo", "groups": ["read"]}]}`,
+	})
+	logs := captureLog(t)
+
+	if err := (&ExportCmd{Check: true, Format: "auto", Target: TargetRoo}).Run(); err == nil {
+		t.Fatal("Run() error = nil, want .roomodes to be out of date")
+	}
+	if strings.Contains(logs.String(), "Removing") {
+		t.Errorf("log = %q, want no removal in check mode", logs.String())
+	}
+	if !strings.Contains(logs.String(), "1 modes in .roomodes have no mode file in .roo/modes: ui") {
+		t.Errorf("log = %q, want the mode without a mode file to be reported", logs.String())
+	}
+}
+
+func TestCompareRoomodesRulesFiles(t *testing.T) {
+	old := `{"customModes": [
+		{"slug": "a", "name": "A", "groups": ["read"], "roleDefinition": "You are Roo",
+		 "rulesFiles": [{"relativePath": "rules-a/1.md", "content": "one\n"}, {"relativePath": "rules-a/2.md", "content": "two\n"}]},
+		{"slug": "b", "name": "B", "groups": ["read"], "roleDefinition": "You are Roo"}
+	]}`
+	new := `{"customModes": [
+		{"slug": "a", "name": "A", "groups": ["read"], "roleDefinition": "You are Roo",
+		 "rulesFiles": [{"relativePath": "rules-a/1.md", "content": "ONE\n"}, {"relativePath": "rules-a/3.md", "content": "three\n"}]},
+		{"slug": "b", "name": "B2", "groups": ["read"], "roleDefinition": "You are Roo"}
+	]}`
+
+	changes, err := compareRoomodes([]byte(old), []byte(new))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []diff.ModeChange{
+		{Slug: "a", Kind: diff.Changed, Fields: []diff.FieldChange{
+			{Field: "rulesFiles[rules-a/1.md]", Old: "one\n", New: "ONE\n", Text: true},
+			{Field: "rulesFiles[rules-a/2.md]", Old: "two\n", Text: true},
+			{Field: "rulesFiles[rules-a/3.md]", New: "three\n", Text: true},
+		}},
+		{Slug: "b", Kind: diff.Changed, Fields: []diff.FieldChange{
+			{Field: "name", Old: "B", New: "B2"},
+		}},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("compareRoomodes() =\n%+v\nwant\n%+v", changes, want)
+	}
+}
+
+func TestExportTarget(t *testing.T) {
+	files := map[string]string{
+		".roo/modes/browse.md": "---\nname: Browse\nroleDefinition: You are Roo\ngroups: [read, browser]\n---\n",
+		".roo/modes/test.md":   "---\nname: Test\nroleDefinition: You are Roo\ngroups: [read]\n---\n",
+	}
+
+	t.Run("roo accepts the browser group", func(t *testing.T) {
+		setupWorkspace(t, files)
+		if err := (&ExportCmd{Format: "json", Target: TargetRoo}).Run(); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if got := readFile(t, ".roomodes"); !strings.Contains(got, `"slug": "browse"`) {
+			t.Errorf(".roomodes = %s, want mode browse", got)
+		}
+	})
+
+	t.Run("kilocode skips modes with the browser group", func(t *testing.T) {
+		setupWorkspace(t, files)
+		logs := captureLog(t)
+		if err := (&ExportCmd{Format: "json", Target: TargetKiloCode}).Run(); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		got := readFile(t, ".kilocodemodes")
+		if strings.Contains(got, `"slug": "browse"`) || !strings.Contains(got, `"slug": "test"`) {
+			t.Errorf(".kilocodemodes = %s, want only mode test", got)
+		}
+		if !strings.Contains(logs.String(), `group \"browser\" is not supported by kilocode`) {
+			t.Errorf("log = %q, want the unsupported group to be reported", logs.String())
+		}
+	})
+
+	t.Run("kilocode rejects a single mode with the browser group", func(t *testing.T) {
+		setupWorkspace(t, files)
+		output := "browse.yaml"
+		err := (&ExportCmd{Single: "browse", OutputFile: &output, Format: "auto", Target: TargetKiloCode}).Run()
+		if err == nil || !strings.Contains(err.Error(), "mode not supported by kilocode") {
+			t.Errorf("Run() error = %v, want the mode to be rejected", err)
+		}
+		if fileutil.FileExists(output) {
+			t.Errorf("export wrote %s", output)
+		}
+	})
+}
+
+func TestModesFileTargetFilter(t *testing.T) {
+	target := &modesFileTarget{name: "test", omitFields: []string{"whenToUse", "source", "rulesFiles"}}
+	m := ExportedMode{
+		Slug:        "test",
+		WhenToUse:   "Use for tests",
+		Source:      "project",
+		RulesFiles:  []RulesFile{{RelativePath: "rules-test/1.md", Content: "one\n"}},
+		Description: "A test mode",
+	}
+
+	got := target.Filter(m)
+	if got.WhenToUse != "" || got.Source != "" || got.RulesFiles != nil {
+		t.Errorf("Filter() = %+v, want whenToUse, source and rulesFiles cleared", got)
+	}
+	if got.Slug != "test" || got.Description != "A test mode" {
+		t.Errorf("Filter() = %+v, want the other fields kept", got)
+	}
+}
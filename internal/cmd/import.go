@@ -2,38 +2,72 @@ package cmd
 
 import (
 	"bufio"
-	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 
 	"github.com/charmbracelet/log"
 	"gopkg.in/yaml.v3"
 
 	"github.com/upamune/roomode/internal/fileutil"
+	"github.com/upamune/roomode/internal/mode"
 )
 
-// ImportedMode represents the structure of a mode in the .roomodes JSON file
+// ImportedMode represents the structure of a mode in the .roomodes file
 type ImportedMode struct {
-	Slug               string        `json:"slug"`
-	Name               string        `json:"name"`
-	Groups             []interface{} `json:"groups"` // Using interface{} because the format might be different
-	CustomInstructions *string       `json:"customInstructions,omitempty"`
-	RoleDefinition     string        `json:"roleDefinition"`
-	Source             string        `json:"source,omitempty"`
+	Slug               string            `yaml:"slug" json:"slug" jsonschema:"required,patternRef=slug" jsonschema_description:"Unique identifier of the mode"`
+	Name               string            `yaml:"name" json:"name" jsonschema:"required,minLength=1" jsonschema_description:"Display name of the mode"`
+	Groups             []mode.GroupEntry `yaml:"groups" json:"groups" jsonschema:"required" jsonschema_description:"Tool groups the mode can use"` // Using interface{} because the format might be different
+	CustomInstructions *string           `yaml:"customInstructions,omitempty" json:"customInstructions,omitempty" jsonschema_description:"Additional instructions added to the system prompt"`
+	RoleDefinition     string            `yaml:"roleDefinition" json:"roleDefinition" jsonschema:"required,minLength=1" jsonschema_description:"Role definition placed at the start of the system prompt"`
+	WhenToUse          string            `yaml:"whenToUse,omitempty" json:"whenToUse,omitempty" jsonschema_description:"When the mode should be used; the Orchestrator uses it to pick a mode to delegate to"`
+	Description        string            `yaml:"description,omitempty" json:"description,omitempty" jsonschema_description:"Short description of the mode shown in the mode selector"`
+	Source             string            `yaml:"source,omitempty" json:"source,omitempty" jsonschema:"enum=global|project" jsonschema_description:"Where the mode is defined"`
+	RulesFiles         []RulesFile       `yaml:"rulesFiles,omitempty" json:"rulesFiles,omitempty" jsonschema_description:"Rule files of the mode, written to .roo/rules-{slug}/"`
+
+	raw *yaml.Node // The mode as read, including fields roomode doesn't know
+}
+
+// UnmarshalJSON decodes a mode and keeps it as read
+func (m *ImportedMode) UnmarshalJSON(data []byte) error {
+	type plain ImportedMode
+	if err := json.Unmarshal(data, (*plain)(m)); err != nil {
+		return err
+	}
+
+	raw, err := jsonToNode(data)
+	if err != nil {
+		return err
+	}
+	m.raw = raw
+	return nil
+}
+
+// UnmarshalYAML decodes a mode and keeps it as read
+func (m *ImportedMode) UnmarshalYAML(node *yaml.Node) error {
+	type plain ImportedMode
+	if err := node.Decode((*plain)(m)); err != nil {
+		return err
+	}
+	m.raw = node
+	return nil
 }
 
-// RoomodesFile represents the structure of the .roomodes JSON file
+// RoomodesFile represents the structure of the .roomodes file, in JSON or YAML
 type RoomodesFile struct {
-	CustomModes []ImportedMode `json:"customModes"`
+	CustomModes []ImportedMode `yaml:"customModes" json:"customModes" jsonschema:"required" jsonschema_description:"Custom modes defined for the project"`
 }
 
-// ImportCmd is a command to import modes from a .roomodes JSON file into the .roo/modes directory
+// ImportCmd is a command to import modes from a .roomodes JSON or YAML file into the .roo/modes directory
 type ImportCmd struct {
-	InputFile *string `arg:"" optional:"" help:"Input JSON file path (default: .roomodes)."`
-	Force     bool    `help:"Overwrite existing mode files without confirmation." default:"false"`
+	InputFile   *string `arg:"" optional:"" help:"Input JSON or YAML file path, or - for standard input (default: .roomodes)."`
+	Force       bool    `help:"Overwrite existing mode files without confirmation." default:"false"`
+	Frontmatter string  `help:"Frontmatter format of the mode files (${enum}). auto keeps the format of existing files, and uses YAML for new ones." enum:"auto,yaml,toml,json" default:"auto"`
 }
 
 // Run executes the ImportCmd
@@ -44,22 +78,21 @@ func (cmd *ImportCmd) Run() error {
 		inputPath = *cmd.InputFile
 	}
 
-	// 2. Read and parse the JSON file
-	data, err := os.ReadFile(inputPath)
+	// 2. Read and parse the file, detecting JSON or YAML from its content
+	var data []byte
+	var err error
+	if inputPath == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(inputPath)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to read input file: %w", err)
 	}
 
-	// Try to parse as a RoomodesFile first (new format)
-	var roomodesFile RoomodesFile
-	if err := json.Unmarshal(data, &roomodesFile); err != nil {
-		// If that fails, try to parse as a direct array of modes (old format)
-		var modes []ImportedMode
-		if err := json.Unmarshal(data, &modes); err != nil {
-			return fmt.Errorf("failed to parse JSON: %w", err)
-		}
-		// If successful, use the direct array
-		roomodesFile.CustomModes = modes
+	roomodesFile, err := parseRoomodes(data)
+	if err != nil {
+		return err
 	}
 
 	// 3. Create the modes directory
@@ -71,152 +104,290 @@ func (cmd *ImportCmd) Run() error {
 	// 4. Process each mode
 	imported := 0
 	skipped := 0
+	unchanged := 0
+	seen := make(map[string]bool, len(roomodesFile.CustomModes))
 
-	for _, mode := range roomodesFile.CustomModes {
-		// Validate mode data
-		if mode.Slug == "" || mode.Name == "" || mode.RoleDefinition == "" {
-			log.Warn("Skipping invalid mode", "slug", mode.Slug)
+	for _, importedMode := range roomodesFile.CustomModes {
+		// Validate mode data against RooCode's schema
+		modeConfig, err := importedMode.toModeConfig()
+		if err == nil {
+			err = mode.ValidateMode(modeConfig)
+		}
+		if err == nil {
+			err = importedMode.validateRulesFiles()
+		}
+		if err != nil {
+			log.Warn("Skipping invalid mode", "slug", importedMode.Slug, "error", err)
 			skipped++
 			continue
 		}
 
+		// Slugs must be unique
+		if seen[importedMode.Slug] {
+			log.Warn("Skipping duplicate mode", "slug", importedMode.Slug)
+			skipped++
+			continue
+		}
+		seen[importedMode.Slug] = true
+
 		// Generate file path
-		filePath := filepath.Join(modesDir, mode.Slug+".md")
+		filePath := filepath.Join(modesDir, importedMode.Slug+".md")
 
-		// Check if file exists
-		if fileutil.FileExists(filePath) && !cmd.Force {
-			// Prompt for confirmation
-			fmt.Printf("File exists: %s. Overwrite? [y/N]: ", filePath)
+		// Generate markdown content, updating an existing file in place
+		exists := fileutil.FileExists(filePath)
+		var content string
+		modeChanged := true
+		regenerated := false
+		if exists {
+			existing, err := os.ReadFile(filePath)
+			if err != nil {
+				log.Error("Failed to read existing file", "file", filePath, "error", err)
+				skipped++
+				continue
+			}
+			existingFormat := mode.DetectFrontmatterFormat(existing)
+			format := cmd.frontmatterFormat(existingFormat)
+			content, err = UpdateModeMarkdown(existing, importedMode, format)
+			modeChanged = err != nil || content != string(existing)
+			regenerated = regeneratesFrontmatter(existing, format)
+
+			// Modes are exported with extends, includes and templates resolved, so an existing file
+			// that resolves to the same mode is kept instead of writing the resolved fields
+			if modeChanged && err == nil && format == existingFormat && sameResolvedMode(filePath, modeConfig) {
+				modeChanged = false
+			}
+
+			// Writing a changed mode into such a file would replace extends, includes and
+			// templates with their result
+			if modeChanged && err == nil {
+				if reason := generatedModeReason(parseExistingMode(filePath)); reason != "" {
+					log.Warn(fmt.Sprintf("Mode file uses %s, apply the changes from %s by hand", reason, inputName(inputPath)), "slug", importedMode.Slug, "file", filePath)
+					skipped++
+					continue
+				}
+			}
+		} else {
+			content, err = GenerateModeMarkdown(importedMode, cmd.frontmatterFormat(""))
+		}
+		if err != nil {
+			log.Error("Failed to generate markdown", "slug", importedMode.Slug, "error", err)
+			skipped++
+			continue
+		}
+
+		if modeChanged {
+			if regenerated {
+				log.Warn("Rewriting the frontmatter from scratch, its comments, key order and unknown keys are not kept", "file", filePath)
+			}
 
-			reader := bufio.NewReader(os.Stdin)
-			response, _ := reader.ReadString('\n')
-			response = strings.TrimSpace(response)
+			// Check if file exists
+			if exists {
+				overwrite, err := cmd.confirmOverwrite(filePath)
+				if err != nil {
+					return err
+				}
+				if !overwrite {
+					skipped++
+					continue
+				}
+			}
 
-			if response != "y" && response != "Y" {
-				log.Info("Skipping existing file", "file", filePath)
+			// Write to file
+			if err := fileutil.WriteFile(filePath, content); err != nil {
+				log.Error("Failed to write file", "file", filePath, "error", err)
 				skipped++
 				continue
 			}
-		} else if fileutil.FileExists(filePath) {
-			log.Info("Overwriting existing file", "file", filePath)
 		}
 
-		// Generate markdown content
-		content, err := GenerateModeMarkdown(mode)
+		// Recreate the mode's rules directory from the bundled files
+		rulesWritten, err := cmd.importRulesFiles(importedMode)
+		if errors.Is(err, errConfirmFromStdin) {
+			return err
+		}
 		if err != nil {
-			log.Error("Failed to generate markdown", "slug", mode.Slug, "error", err)
+			log.Error("Failed to import rules files", "slug", importedMode.Slug, "error", err)
 			skipped++
 			continue
 		}
 
-		// Write to file
-		if err := fileutil.WriteFile(filePath, content); err != nil {
-			log.Error("Failed to write file", "file", filePath, "error", err)
-			skipped++
+		if !modeChanged && rulesWritten == 0 {
+			log.Info("Mode is up to date", "slug", importedMode.Slug, "file", filePath)
+			unchanged++
 			continue
 		}
 
-		log.Info("Imported mode", "slug", mode.Slug, "file", filePath)
+		log.Info("Imported mode", "slug", importedMode.Slug, "file", filePath)
 		imported++
 	}
 
 	// 5. Display summary
-	log.Info(fmt.Sprintf("Import complete: %d modes imported, %d skipped", imported, skipped))
+	log.Info(fmt.Sprintf("Import complete: %d modes imported, %d unchanged, %d skipped", imported, unchanged, skipped))
 
 	return nil
 }
 
-// GenerateModeMarkdown creates markdown content with frontmatter from an imported mode
-func GenerateModeMarkdown(mode ImportedMode) (string, error) {
-	// Create frontmatter data structure
-	frontmatterData := map[string]interface{}{
-		"name":          mode.Name,
-		"roleDefinition": mode.RoleDefinition,
-	}
-
-	// Add source if it exists
-	if mode.Source != "" {
-		frontmatterData["source"] = mode.Source
-	}
-
-	// Process groups to ensure proper YAML formatting
-	processedGroups := make([]interface{}, 0, len(mode.Groups))
-	for _, groupInterface := range mode.Groups {
-		switch group := groupInterface.(type) {
-		case string:
-			// Simple string group
-			processedGroups = append(processedGroups, group)
-		case []interface{}:
-			// Array format [string, options]
-			if len(group) == 2 {
-				name, ok := group[0].(string)
-				if ok {
-					switch options := group[1].(type) {
-					case map[string]interface{}:
-						// Convert array format to map format
-						groupMap := map[string]interface{}{
-							name: options,
-						}
-						processedGroups = append(processedGroups, groupMap)
-					case map[interface{}]interface{}:
-						// Convert interface{} keys to string keys
-						stringOptions := make(map[string]interface{})
-						for k, v := range options {
-							if keyStr, ok := k.(string); ok {
-								stringOptions[keyStr] = v
-							}
-						}
-						groupMap := map[string]interface{}{
-							name: stringOptions,
-						}
-						processedGroups = append(processedGroups, groupMap)
-					default:
-						// Just add the name as a simple string
-						processedGroups = append(processedGroups, name)
-					}
-				} else {
-					// Just add as is if not properly structured
-					processedGroups = append(processedGroups, groupInterface)
-				}
-			} else {
-				// Just add as is if not properly structured
-				processedGroups = append(processedGroups, groupInterface)
+// frontmatterFormat returns the frontmatter format to write a mode file in
+// existingFormat is the format of the existing file, or empty for a new file
+func (cmd *ImportCmd) frontmatterFormat(existingFormat string) string {
+	switch {
+	case cmd.Frontmatter != "auto":
+		return cmd.Frontmatter
+	case existingFormat != "":
+		return existingFormat
+	}
+	return mode.FrontmatterYAML
+}
+
+// errConfirmFromStdin is returned when an overwrite must be confirmed but the modes are read from
+// standard input, which leaves nothing to read the answer from
+var errConfirmFromStdin = errors.New("can't confirm overwriting files when reading modes from standard input (use --force)")
+
+// confirmOverwrite asks whether an existing file should be overwritten
+// Returns errConfirmFromStdin instead of asking when the input file is standard input
+func (cmd *ImportCmd) confirmOverwrite(path string) (bool, error) {
+	if !cmd.Force && cmd.InputFile != nil && *cmd.InputFile == "-" {
+		return false, fmt.Errorf("%s exists: %w", path, errConfirmFromStdin)
+	}
+	return confirmOverwrite(path, cmd.Force), nil
+}
+
+// confirmOverwrite asks whether an existing file should be overwritten
+// Doesn't ask when force is set
+func confirmOverwrite(path string, force bool) bool {
+	if force {
+		log.Info("Overwriting existing file", "file", path)
+		return true
+	}
+
+	// Prompt for confirmation
+	fmt.Printf("File exists: %s. Overwrite? [y/N]: ", path)
+
+	reader := bufio.NewReader(os.Stdin)
+	response, _ := reader.ReadString('\n')
+	response = strings.TrimSpace(response)
+
+	if response != "y" && response != "Y" {
+		log.Info("Skipping existing file", "file", path)
+		return false
+	}
+	return true
+}
+
+// importRulesFiles writes the rule files bundled with a mode and returns how many were written
+// Files that already have the same content are left untouched
+func (cmd *ImportCmd) importRulesFiles(m ImportedMode) (int, error) {
+	written := 0
+	for _, rulesFile := range m.RulesFiles {
+		filePath, err := rulesFilePath(m.Slug, rulesFile.RelativePath)
+		if err != nil {
+			return written, err
+		}
+
+		if fileutil.FileExists(filePath) {
+			existing, err := os.ReadFile(filePath)
+			if err != nil {
+				return written, fmt.Errorf("failed to read rules file: %w", err)
+			}
+			if string(existing) == rulesFile.Content {
+				continue
 			}
-		case map[string]interface{}:
-			// Map format - already structured correctly
-			processedGroups = append(processedGroups, group)
-		default:
-			// Just add as is for any other type
-			processedGroups = append(processedGroups, groupInterface)
+			overwrite, err := cmd.confirmOverwrite(filePath)
+			if err != nil {
+				return written, err
+			}
+			if !overwrite {
+				continue
+			}
+		}
+
+		if err := fileutil.WriteFile(filePath, rulesFile.Content); err != nil {
+			return written, err
 		}
+		log.Info("Imported rules file", "slug", m.Slug, "file", filePath)
+		written++
 	}
+	return written, nil
+}
 
-	// Add processed groups to frontmatter
-	frontmatterData["groups"] = processedGroups
+// parseExistingMode parses a mode file without resolving extends, or returns nil if it can't be parsed
+func parseExistingMode(path string) *mode.Config {
+	existing, err := mode.ParseModeFile(path)
+	if err != nil {
+		return nil
+	}
+	return existing
+}
 
-	// Generate YAML frontmatter manually since the frontmatter package doesn't provide a Marshal function
-	var buf bytes.Buffer
-	
-	// Start frontmatter
-	buf.WriteString("---\n")
-	
-	// Marshal to YAML
-	yamlData, err := yaml.Marshal(frontmatterData)
+// inputName returns the name of an input file for messages
+func inputName(path string) string {
+	if path == "-" {
+		return "standard input"
+	}
+	return path
+}
+
+// sameResolvedMode reports whether the mode file at path resolves to m
+func sameResolvedMode(path string, m *mode.Config) bool {
+	existing, err := mode.LoadModeFile(path)
+	if err != nil {
+		return false
+	}
+
+	project, err := currentProject()
+	if err != nil {
+		return false
+	}
+	existing, err = mode.RenderTemplates(existing, project)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal frontmatter: %w", err)
+		return false
 	}
-	
-	// Write YAML content
-	buf.Write(yamlData)
-	
-	// End frontmatter
-	buf.WriteString("---\n")
 
-	// Add custom instructions to the body if present
-	if mode.CustomInstructions != nil {
-		buf.WriteString("\n")
-		buf.WriteString(*mode.CustomInstructions)
+	instructions := func(c *mode.Config) string {
+		if c.CustomInstructions == nil {
+			return ""
+		}
+		return strings.TrimSpace(*c.CustomInstructions)
 	}
 
-	return buf.String(), nil
+	return existing.Name == m.Name &&
+		existing.RoleDefinition == m.RoleDefinition &&
+		existing.WhenToUse == m.WhenToUse &&
+		existing.Description == m.Description &&
+		existing.Source == m.Source &&
+		instructions(existing) == instructions(m) &&
+		reflect.DeepEqual(existing.GroupsParsed, m.GroupsParsed)
+}
+
+// toModeConfig converts an imported mode to a mode.Config so it can be validated
+func (m ImportedMode) toModeConfig() (*mode.Config, error) {
+	parsedGroups, err := mode.ParseGroupEntries(m.Groups)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mode.Config{
+		Slug:               m.Slug,
+		Name:               m.Name,
+		GroupsRaw:          m.Groups,
+		GroupsParsed:       parsedGroups,
+		RoleDefinition:     m.RoleDefinition,
+		WhenToUse:          m.WhenToUse,
+		Description:        m.Description,
+		CustomInstructions: m.CustomInstructions,
+		Source:             m.Source,
+		Keys:               m.keys(),
+	}, nil
+}
+
+// keys returns the fields present in the mode as read, so blank optional fields are checked
+// Modes that weren't read from a file only have their non-empty fields
+func (m ImportedMode) keys() map[string]bool {
+	if m.raw != nil {
+		return nodeKeys(m.raw)
+	}
+	return map[string]bool{
+		"whenToUse":   m.WhenToUse != "",
+		"description": m.Description != "",
+	}
 }
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setupWorkspace changes to a temporary directory containing files, keyed by slash separated path
+func setupWorkspace(t *testing.T, files map[string]string) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	for path, content := range files {
+		path = filepath.FromSlash(path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readFile returns the content of a slash separated path
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.FromSlash(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestImportSkipsGeneratedModes(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{
+			name: "extends",
+			files: map[string]string{
+				".roo/modes/base.md": "---\nname: Base\nroleDefinition: You are Roo\ngroups: [read]\n---\nBase instructions\n",
+				".roo/modes/gen.md":  "---\nname: Generated\nextends: base\n---\nMore instructions\n",
+			},
+		},
+		{
+			name: "includes",
+			files: map[string]string{
+				".roo/snippets/git.md": "Commit often\n",
+				".roo/modes/gen.md":    "---\nname: Generated\nroleDefinition: You are Roo\ngroups: [read]\n---\n<!-- @include ../snippets/git.md -->\n",
+			},
+		},
+		{
+			name: "templates",
+			files: map[string]string{
+				".roo/modes/gen.md": "---\nname: Generated\nroleDefinition: You are Roo in {{ .Project.Name }}\ngroups: [read]\ntemplate: true\n---\n",
+			},
+		},
+		{
+			name: "vars",
+			files: map[string]string{
+				".roo/modes/gen.md": "---\nname: Generated\nroleDefinition: You are Roo\ngroups: [read]\nvars:\n  lang: Go\n---\nWrite {{ .Vars.lang }}\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.files[".roo/modes/plain.md"] = "---\nname: Plain\nroleDefinition: You are Roo\ngroups: [read]\n---\n"
+			tt.files[".roomodes"] = `{"customModes": [
+				{"slug": "gen", "name": "Generated", "roleDefinition": "You are Roo, edited", "groups": ["read"], "customInstructions": "Edited"},
+				{"slug": "plain", "name": "Plain", "roleDefinition": "You are Roo, edited", "groups": ["read"]}
+			]}`
+			setupWorkspace(t, tt.files)
+
+			if err := (&ImportCmd{Force: true, Frontmatter: "auto"}).Run(); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if got := readFile(t, ".roo/modes/gen.md"); got != tt.files[".roo/modes/gen.md"] {
+				t.Errorf("gen.md was rewritten:\n%s", got)
+			}
+			if got, want := readFile(t, ".roo/modes/plain.md"), "---\nname: Plain\nroleDefinition: You are Roo, edited\ngroups: [read]\n---\n"; got != want {
+				t.Errorf("plain.md =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestImportSkipsBlankOptionalFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		roomodes string
+	}{
+		{
+			name:     "JSON",
+			roomodes: `{"customModes": [{"slug": "test", "name": "Test", "roleDefinition": "You are Roo", "groups": ["read"], "whenToUse": ""}]}`,
+		},
+		{
+			name:     "YAML",
+			roomodes: "customModes:\n  - slug: test\n    name: Test\n    roleDefinition: You are Roo\n    groups: [read]\n    description: \" \"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupWorkspace(t, map[string]string{".roomodes": tt.roomodes})
+
+			if err := (&ImportCmd{Force: true, Frontmatter: "auto"}).Run(); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if _, err := os.Stat(filepath.FromSlash(".roo/modes/test.md")); !os.IsNotExist(err) {
+				t.Errorf("import wrote a mode file for a mode with a blank optional field (stat error = %v)", err)
+			}
+		})
+	}
+}
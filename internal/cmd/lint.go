@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/upamune/roomode/internal/lint"
+	"github.com/upamune/roomode/internal/mode"
+)
+
+// LintCmd is a command to run style checks on mode files
+type LintCmd struct {
+	Paths     []string `arg:"" optional:"" help:"Mode files or directories to lint (default: .roo/modes)."`
+	Config    string   `help:"Path to the lint configuration file (default: .roomode-lint.yaml)."`
+	Format    string   `short:"f" enum:"text,github,sarif" default:"text" help:"Output format (text, github, sarif)."`
+	ListRules bool     `help:"List available lint rules and exit." default:"false"`
+}
+
+// Run executes the LintCmd
+func (cmd *LintCmd) Run() error {
+	if cmd.ListRules {
+		for _, rule := range lint.Rules {
+			fmt.Printf("%s (%s)\n", rule.ID, rule.DefaultSeverity)
+			fmt.Printf("   %s\n", rule.Description)
+		}
+		return nil
+	}
+
+	// 1. Load lint configuration
+	config, err := lint.LoadConfig(cmd.Config)
+	if err != nil {
+		return err
+	}
+
+	// 2. Collect mode files to lint
+	files, err := collectModeFiles(cmd.Paths)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		log.Info("No custom modes found to lint")
+		return nil
+	}
+
+	// 3. Run the rules on each file
+	var diags mode.Diagnostics
+	for _, file := range files {
+		diags = append(diags, lint.LintFile(config, file)...)
+	}
+
+	// 4. Print diagnostics
+	if err := writeDiagnostics(cmd.Format, diags); err != nil {
+		return err
+	}
+
+	errorCount := 0
+	for _, d := range diags {
+		if d.IsError() {
+			errorCount++
+		}
+	}
+
+	// 5. Display results
+	if errorCount > 0 {
+		return fmt.Errorf("lint failed: %d errors and %d warnings in %d files", errorCount, len(diags)-errorCount, len(files))
+	}
+
+	log.Info(fmt.Sprintf("Lint complete: %d warnings in %d files", len(diags), len(files)))
+	return nil
+}
@@ -38,7 +38,7 @@ func (cmd *ListCmd) Run() error {
 		base := filepath.Base(file)
 		slug := strings.TrimSuffix(base, filepath.Ext(base))
 
-		modeConfig, err := mode.ParseModeFile(file)
+		modeConfig, err := mode.LoadModeFile(file)
 		if err != nil {
 			log.Error("Failed to parse mode file", "file", file, "error", err)
 			continue
@@ -48,6 +48,15 @@ func (cmd *ListCmd) Run() error {
 			// Detailed display mode
 			fmt.Printf("%d. %s (%s)\n", i+1, modeConfig.Name, slug)
 			fmt.Printf("   Path: %s\n", file)
+			if modeConfig.Extends != "" {
+				fmt.Printf("   Extends: %s\n", modeConfig.Extends)
+			}
+			if modeConfig.Description != "" {
+				fmt.Printf("   Description: %s\n", modeConfig.Description)
+			}
+			if modeConfig.WhenToUse != "" {
+				fmt.Printf("   When to use: %s\n", modeConfig.WhenToUse)
+			}
 			fmt.Printf("   Groups: ")
 			for j, group := range modeConfig.GroupsParsed {
 				if j > 0 {
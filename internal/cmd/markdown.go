@@ -0,0 +1,627 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/upamune/roomode/internal/mode"
+)
+
+// frontmatterField is a frontmatter key and its value in a generated mode file
+type frontmatterField struct {
+	key   string
+	value interface{}
+}
+
+// frontmatterFields returns the frontmatter fields of a mode in the order they are written,
+// which follows the order of RooCode's custom mode schema
+// Optional fields that aren't set have an empty string value
+func frontmatterFields(m ImportedMode) []frontmatterField {
+	return []frontmatterField{
+		{key: "name", value: m.Name},
+		{key: "roleDefinition", value: m.RoleDefinition},
+		{key: "whenToUse", value: m.WhenToUse},
+		{key: "description", value: m.Description},
+		{key: "groups", value: frontmatterGroups(m.Groups)},
+		{key: "source", value: m.Source},
+	}
+}
+
+// GenerateModeMarkdown creates markdown content with frontmatter in the given format
+// (one of mode.FrontmatterFormats) from an imported mode
+func GenerateModeMarkdown(m ImportedMode, format string) (string, error) {
+	var frontmatter string
+	var err error
+	switch format {
+	case mode.FrontmatterTOML:
+		frontmatter, err = generateTOMLFrontmatter(m)
+	case mode.FrontmatterJSON:
+		frontmatter, err = generateJSONFrontmatter(m)
+	default:
+		frontmatter, err = generateYAMLFrontmatter(m)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(frontmatter)
+
+	// Add custom instructions to the body if present
+	if m.CustomInstructions != nil {
+		buf.WriteString("\n")
+		buf.WriteString(*m.CustomInstructions)
+	}
+
+	return buf.String(), nil
+}
+
+// generateYAMLFrontmatter returns the YAML frontmatter block of a mode, including delimiters
+func generateYAMLFrontmatter(mode ImportedMode) (string, error) {
+	// Build the frontmatter as a node so the keys keep their order
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, field := range frontmatterFields(mode) {
+		if field.value == "" {
+			continue
+		}
+		value, err := frontmatterValueNode(field.value)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal frontmatter: %w", err)
+		}
+		root.Content = append(root.Content, frontmatterKeyNode(field.key), value)
+	}
+
+	// Generate YAML frontmatter manually since the frontmatter package doesn't provide a Marshal function
+	var buf bytes.Buffer
+
+	// Start frontmatter
+	buf.WriteString("---\n")
+
+	// Marshal to YAML
+	yamlData, err := yaml.Marshal(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal frontmatter: %w", err)
+	}
+
+	// Write YAML content
+	buf.Write(yamlData)
+
+	// End frontmatter
+	buf.WriteString("---\n")
+
+	return buf.String(), nil
+}
+
+// generateTOMLFrontmatter returns the TOML frontmatter block of a mode, delimited by "+++"
+// Multi-line strings are written as multi-line strings and groups one per line
+func generateTOMLFrontmatter(m ImportedMode) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteString("+++\n")
+
+	for _, field := range frontmatterFields(m) {
+		if field.value == "" {
+			continue
+		}
+
+		var value string
+		var err error
+		switch v := field.value.(type) {
+		case string:
+			value, err = tomlString(v)
+		case []interface{}:
+			elements := make([]string, 0, len(v))
+			for _, element := range v {
+				encoded, err := tomlArrayElement(element)
+				if err != nil {
+					return "", fmt.Errorf("failed to marshal frontmatter: %w", err)
+				}
+				elements = append(elements, "  "+encoded+",\n")
+			}
+			value = "[\n" + strings.Join(elements, "") + "]"
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal frontmatter: %w", err)
+		}
+
+		fmt.Fprintf(&buf, "%s = %s\n", field.key, value)
+	}
+
+	buf.WriteString("+++\n")
+	return buf.String(), nil
+}
+
+// tomlString encodes a TOML string
+// Strings with line breaks use a multi-line basic string to stay readable
+func tomlString(s string) (string, error) {
+	if strings.Contains(s, "\n") && !strings.ContainsFunc(s, func(r rune) bool {
+		return unicode.IsControl(r) && r != '\n' && r != '\t'
+	}) {
+		escaped := strings.ReplaceAll(s, `\`, `\\`)
+		escaped = strings.ReplaceAll(escaped, `"""`, `""\"`)
+		if strings.HasSuffix(escaped, `"`) {
+			escaped = strings.TrimSuffix(escaped, `"`) + `\"`
+		}
+		return `"""` + "\n" + escaped + `"""`, nil
+	}
+
+	return tomlArrayElement(s)
+}
+
+// tomlArrayElement encodes a value as it appears inside a TOML array, so maps are inline tables
+func tomlArrayElement(v interface{}) (string, error) {
+	switch v := normalizeValue(v).(type) {
+	case string:
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(v); err != nil {
+			return "", err
+		}
+		// JSON string escapes are valid in TOML basic strings
+		return strings.TrimSuffix(buf.String(), "\n"), nil
+	case []interface{}:
+		elements := make([]string, 0, len(v))
+		for _, element := range v {
+			encoded, err := tomlArrayElement(element)
+			if err != nil {
+				return "", err
+			}
+			elements = append(elements, encoded)
+		}
+		return "[" + strings.Join(elements, ", ") + "]", nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		members := make([]string, 0, len(keys))
+		for _, key := range keys {
+			value, err := tomlArrayElement(v[key])
+			if err != nil {
+				return "", err
+			}
+			if !tomlBareKey.MatchString(key) {
+				key, _ = tomlArrayElement(key)
+			}
+			members = append(members, key+" = "+value)
+		}
+		return "{" + strings.Join(members, ", ") + "}", nil
+	case bool, int, int64, float64:
+		return fmt.Sprint(v), nil
+	}
+	return "", fmt.Errorf("unsupported value of type %T", v)
+}
+
+// tomlBareKey matches TOML keys that don't need quotes
+var tomlBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// normalizeValue converts group options decoded into typed values, such as *mode.GroupOptions,
+// to the generic values of a decoded document
+func normalizeValue(v interface{}) interface{} {
+	switch v.(type) {
+	case string, []interface{}, map[string]interface{}, bool, int, int64, float64:
+		return v
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return v
+	}
+	return generic
+}
+
+// generateJSONFrontmatter returns the JSON frontmatter block of a mode, delimited by ";;;"
+func generateJSONFrontmatter(m ImportedMode) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteString(";;;\n{\n")
+
+	var members []string
+	for _, field := range frontmatterFields(m) {
+		if field.value == "" {
+			continue
+		}
+		key, err := json.Marshal(field.key)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal frontmatter: %w", err)
+		}
+		value, err := json.MarshalIndent(field.value, "  ", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal frontmatter: %w", err)
+		}
+		members = append(members, fmt.Sprintf("  %s: %s", key, value))
+	}
+	buf.WriteString(strings.Join(members, ",\n"))
+
+	buf.WriteString("\n}\n;;;\n")
+	return buf.String(), nil
+}
+
+// UpdateModeMarkdown applies an imported mode to the content of an existing mode file
+// Only the fields that changed are rewritten: key order, comments, scalar styles and
+// unknown keys of the existing frontmatter are kept, and the content is returned
+// unchanged when the file already matches the mode
+// Files are regenerated in the given format unless both the file and format are YAML, which
+// drops their comments, key order and unknown keys (see regeneratesFrontmatter)
+func UpdateModeMarkdown(existing []byte, m ImportedMode, format string) (string, error) {
+	head, yamlData, tail, ok := mode.SplitYAMLFrontmatter(existing)
+	if !ok || format != mode.FrontmatterYAML {
+		return GenerateModeMarkdown(m, format)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(yamlData, &doc); err != nil || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return GenerateModeMarkdown(m, format)
+	}
+
+	changed, err := updateFrontmatter(doc.Content[0], frontmatterFields(m))
+	if err != nil {
+		return "", err
+	}
+	if changed {
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(yamlIndent(yamlData))
+		if err := encoder.Encode(&doc); err != nil {
+			return "", fmt.Errorf("failed to marshal frontmatter: %w", err)
+		}
+		if err := encoder.Close(); err != nil {
+			return "", fmt.Errorf("failed to marshal frontmatter: %w", err)
+		}
+		yamlData = buf.Bytes()
+	}
+
+	// The body is only rewritten when the custom instructions changed
+	delimiter, body := tail, []byte(nil)
+	if i := bytes.IndexByte(tail, '\n'); i >= 0 {
+		delimiter, body = tail[:i+1], tail[i+1:]
+	}
+	instructions := ""
+	if m.CustomInstructions != nil {
+		instructions = strings.TrimSpace(*m.CustomInstructions)
+	}
+	if strings.TrimSpace(string(body)) != instructions {
+		var newTail bytes.Buffer
+		newTail.Write(bytes.TrimRight(delimiter, "\r\n"))
+		newTail.WriteString("\n")
+		if m.CustomInstructions != nil {
+			newTail.WriteString("\n")
+			newTail.WriteString(*m.CustomInstructions)
+		}
+		tail = newTail.Bytes()
+	}
+
+	var buf bytes.Buffer
+	buf.Write(head)
+	buf.Write(yamlData)
+	buf.Write(tail)
+	return buf.String(), nil
+}
+
+// regeneratesFrontmatter reports whether UpdateModeMarkdown writes the frontmatter of existing
+// from scratch instead of updating it in place
+func regeneratesFrontmatter(existing []byte, format string) bool {
+	_, _, _, ok := mode.SplitYAMLFrontmatter(existing)
+	return !ok || format != mode.FrontmatterYAML
+}
+
+// updateFrontmatter sets the fields in a frontmatter mapping node
+// Values that are already equal are kept as they are, missing keys are inserted after the
+// preceding field, and optional fields that aren't set are removed. Groups are updated entry
+// by entry (see updateGroupNodes)
+// Reports whether the mapping was changed
+func updateFrontmatter(root *yaml.Node, fields []frontmatterField) (bool, error) {
+	changed := false
+	prev := -1 // Index of the key of the previously handled field
+
+	for _, field := range fields {
+		i := mappingKeyIndex(root, field.key)
+
+		if field.value == "" {
+			if i >= 0 {
+				root.Content = append(root.Content[:i], root.Content[i+2:]...)
+				if prev > i {
+					prev -= 2
+				}
+				changed = true
+			}
+			continue
+		}
+
+		if i >= 0 {
+			if !sameFrontmatterValue(field.key, root.Content[i+1], field.value) {
+				if field.key == "groups" {
+					updated, err := updateGroupNodes(root.Content[i+1], field.value.([]interface{}))
+					if err != nil {
+						return false, fmt.Errorf("failed to marshal frontmatter: %w", err)
+					}
+					if updated {
+						prev = i
+						changed = true
+						continue
+					}
+				}
+				value, err := frontmatterValueNode(field.value)
+				if err != nil {
+					return false, fmt.Errorf("failed to marshal frontmatter: %w", err)
+				}
+				keepNodeStyle(root.Content[i+1], value)
+				root.Content[i+1] = value
+				changed = true
+			}
+			prev = i
+			continue
+		}
+
+		value, err := frontmatterValueNode(field.value)
+		if err != nil {
+			return false, fmt.Errorf("failed to marshal frontmatter: %w", err)
+		}
+		at := prev + 2
+		if prev < 0 {
+			at = 0
+		}
+		content := append([]*yaml.Node{}, root.Content[:at]...)
+		content = append(content, frontmatterKeyNode(field.key), value)
+		root.Content = append(content, root.Content[at:]...)
+		prev = at
+		changed = true
+	}
+
+	return changed, nil
+}
+
+// sameFrontmatterValue reports whether a frontmatter node already holds value
+// Groups are compared after parsing, so equivalent entry formats are considered equal
+func sameFrontmatterValue(key string, node *yaml.Node, value interface{}) bool {
+	if key == "groups" {
+		var existing []mode.GroupEntry
+		if err := node.Decode(&existing); err != nil {
+			return false
+		}
+		existingGroups, err := mode.ParseGroupEntries(existing)
+		if err != nil {
+			return false
+		}
+		var groups []mode.GroupEntry
+		for _, group := range value.([]interface{}) {
+			groups = append(groups, group)
+		}
+		newGroups, err := mode.ParseGroupEntries(groups)
+		if err != nil {
+			return false
+		}
+		return reflect.DeepEqual(existingGroups, newGroups)
+	}
+
+	var existing string
+	if node.Kind != yaml.ScalarNode || node.Decode(&existing) != nil {
+		return false
+	}
+	return existing == value
+}
+
+// updateGroupNodes updates a groups sequence node in place to hold groups
+// Entries of groups that are kept are reused with their comments and styles, and only their
+// options that changed are rewritten. Reports false, leaving node as it is, when it isn't a
+// list of valid group entries
+func updateGroupNodes(node *yaml.Node, groups []interface{}) (bool, error) {
+	if node.Kind != yaml.SequenceNode {
+		return false, nil
+	}
+
+	entries := make(map[string]*yaml.Node, len(node.Content))
+	options := make(map[string]*mode.GroupOptions, len(node.Content))
+	for _, entry := range node.Content {
+		group, ok := parseGroupNode(entry)
+		if !ok {
+			return false, nil
+		}
+		entries[group.Name] = entry
+		options[group.Name] = group.Options
+	}
+
+	var entryGroups []mode.GroupEntry
+	for _, value := range groups {
+		entryGroups = append(entryGroups, value)
+	}
+	parsed, err := mode.ParseGroupEntries(entryGroups)
+	if err != nil {
+		return false, nil
+	}
+
+	content := make([]*yaml.Node, 0, len(groups))
+	for i, group := range parsed {
+		entry, ok := entries[group.Name]
+		switch {
+		case !ok:
+			if entry, err = frontmatterValueNode(groups[i]); err != nil {
+				return false, err
+			}
+		case reflect.DeepEqual(options[group.Name], group.Options):
+		case !updateGroupOptions(entry, group.Options):
+			replacement, err := frontmatterValueNode(groups[i])
+			if err != nil {
+				return false, err
+			}
+			keepNodeStyle(entry, replacement)
+			// A line comment of a mapping is written after its first key
+			if replacement.Kind == yaml.MappingNode && len(replacement.Content) > 0 {
+				replacement.Content[0].LineComment, replacement.LineComment = replacement.LineComment, ""
+			}
+			entry = replacement
+		}
+		content = append(content, entry)
+	}
+
+	node.Content = content
+	return true, nil
+}
+
+// parseGroupNode parses a single entry of a groups sequence node
+func parseGroupNode(entry *yaml.Node) (mode.ParsedGroupEntry, bool) {
+	var value mode.GroupEntry
+	if err := entry.Decode(&value); err != nil {
+		return mode.ParsedGroupEntry{}, false
+	}
+	parsed, err := mode.ParseGroupEntries([]mode.GroupEntry{value})
+	if err != nil {
+		return mode.ParsedGroupEntry{}, false
+	}
+	return parsed[0], true
+}
+
+// updateGroupOptions sets the options of a group entry with an options mapping in place
+// Unknown option keys are kept. Reports false if the entry has no options mapping to update,
+// or the options are removed
+func updateGroupOptions(entry *yaml.Node, options *mode.GroupOptions) bool {
+	if options == nil || (entry.Kind != yaml.MappingNode && entry.Kind != yaml.SequenceNode) ||
+		len(entry.Content) != 2 || entry.Content[1].Kind != yaml.MappingNode {
+		return false
+	}
+
+	node := entry.Content[1]
+	setOption := func(key string, value *string) {
+		i := mappingKeyIndex(node, key)
+		switch {
+		case value == nil && i >= 0:
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+		case value == nil:
+		case i >= 0:
+			if sameFrontmatterValue(key, node.Content[i+1], *value) {
+				return
+			}
+			replacement := &yaml.Node{}
+			replacement.SetString(*value)
+			keepNodeStyle(node.Content[i+1], replacement)
+			node.Content[i+1] = replacement
+		default:
+			replacement := &yaml.Node{}
+			replacement.SetString(*value)
+			node.Content = append(node.Content, frontmatterKeyNode(key), replacement)
+		}
+	}
+	setOption("fileRegex", options.FileRegex)
+	setOption("description", options.Description)
+	return true
+}
+
+// keepNodeStyle carries the comments and quoting or block style of a replaced scalar over to its replacement
+func keepNodeStyle(old, value *yaml.Node) {
+	value.HeadComment = old.HeadComment
+	value.LineComment = old.LineComment
+	value.FootComment = old.FootComment
+
+	if old.Kind != yaml.ScalarNode || value.Kind != yaml.ScalarNode {
+		return
+	}
+	switch {
+	case old.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) != 0:
+		value.Style = old.Style
+	case old.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 && strings.Contains(value.Value, "\n"):
+		value.Style = old.Style
+	}
+}
+
+// mappingKeyIndex returns the index of key in a mapping node's content, or -1
+func mappingKeyIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func frontmatterKeyNode(key string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+}
+
+func frontmatterValueNode(value interface{}) (*yaml.Node, error) {
+	node := &yaml.Node{}
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// yamlIndent returns the indentation used by YAML content
+// Defaults to the indentation of yaml.Marshal, which GenerateModeMarkdown uses
+func yamlIndent(data []byte) int {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if indent := len(line) - len(trimmed); indent > 0 && trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return indent
+		}
+	}
+	return 4
+}
+
+// frontmatterGroups converts groups from the .roomodes formats to the map format used in frontmatter
+func frontmatterGroups(groups []mode.GroupEntry) []interface{} {
+	// Process groups to ensure proper YAML formatting
+	processedGroups := make([]interface{}, 0, len(groups))
+	for _, groupInterface := range groups {
+		switch group := groupInterface.(type) {
+		case string:
+			// Simple string group
+			processedGroups = append(processedGroups, group)
+		case []interface{}:
+			// Array format [string, options]
+			if len(group) == 2 {
+				name, ok := group[0].(string)
+				if ok {
+					switch options := group[1].(type) {
+					case map[string]interface{}:
+						// Convert array format to map format
+						groupMap := map[string]interface{}{
+							name: options,
+						}
+						processedGroups = append(processedGroups, groupMap)
+					case map[interface{}]interface{}:
+						// Convert interface{} keys to string keys
+						stringOptions := make(map[string]interface{})
+						for k, v := range options {
+							if keyStr, ok := k.(string); ok {
+								stringOptions[keyStr] = v
+							}
+						}
+						groupMap := map[string]interface{}{
+							name: stringOptions,
+						}
+						processedGroups = append(processedGroups, groupMap)
+					default:
+						// Just add the name as a simple string
+						processedGroups = append(processedGroups, name)
+					}
+				} else {
+					// Just add as is if not properly structured
+					processedGroups = append(processedGroups, groupInterface)
+				}
+			} else {
+				// Just add as is if not properly structured
+				processedGroups = append(processedGroups, groupInterface)
+			}
+		case map[string]interface{}:
+			// Map format - already structured correctly
+			processedGroups = append(processedGroups, group)
+		default:
+			// Just add as is for any other type
+			processedGroups = append(processedGroups, groupInterface)
+		}
+	}
+	return processedGroups
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/upamune/roomode/internal/mode"
+)
+
+func TestUpdateModeMarkdownGroups(t *testing.T) {
+	const existing = `---
+name: Docs
+roleDefinition: You are Roo
+groups:
+  - read # always
+  # only md
+  - edit:
+      fileRegex: '\.md$'
+      description: Markdown files
+  - [command, {fileRegex: "\\.sh$"}]
+---
+Write docs.
+`
+
+	tests := []struct {
+		name   string
+		groups []mode.GroupEntry
+		want   string
+	}{
+		{
+			name: "option changed",
+			groups: []mode.GroupEntry{
+				"read",
+				[]interface{}{"edit", map[string]interface{}{"fileRegex": `\.mdx?$`, "description": "Markdown files"}},
+				[]interface{}{"command", map[string]interface{}{"fileRegex": `\.sh$`}},
+			},
+			want: `---
+name: Docs
+roleDefinition: You are Roo
+groups:
+  - read # always
+  # only md
+  - edit:
+      fileRegex: '\.mdx?$'
+      description: Markdown files
+  - [command, {fileRegex: "\\.sh$"}]
+---
+Write docs.
+`,
+		},
+		{
+			name: "options removed and added",
+			groups: []mode.GroupEntry{
+				[]interface{}{"read", map[string]interface{}{"fileRegex": "123"}},
+				[]interface{}{"edit", map[string]interface{}{"fileRegex": `\.md$`}},
+				"command",
+			},
+			want: `---
+name: Docs
+roleDefinition: You are Roo
+groups:
+  - read: # always
+      fileRegex: "123"
+  # only md
+  - edit:
+      fileRegex: '\.md$'
+  - command
+---
+Write docs.
+`,
+		},
+		{
+			name: "groups removed and added",
+			groups: []mode.GroupEntry{
+				[]interface{}{"edit", map[string]interface{}{"fileRegex": `\.md$`, "description": "Markdown files"}},
+				"browser",
+			},
+			want: `---
+name: Docs
+roleDefinition: You are Roo
+groups:
+  # only md
+  - edit:
+      fileRegex: '\.md$'
+      description: Markdown files
+  - browser
+---
+Write docs.
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instructions := "Write docs."
+			m := ImportedMode{Slug: "docs", Name: "Docs", RoleDefinition: "You are Roo", Groups: tt.groups, CustomInstructions: &instructions}
+			got, err := UpdateModeMarkdown([]byte(existing), m, mode.FrontmatterYAML)
+			if err != nil {
+				t.Fatalf("UpdateModeMarkdown() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("UpdateModeMarkdown() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
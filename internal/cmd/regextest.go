@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/upamune/roomode/internal/fileutil"
+	"github.com/upamune/roomode/internal/mode"
+)
+
+// RegexTestCmd is a command to check which groups of a mode allow given paths
+type RegexTestCmd struct {
+	Slug  string   `arg:"" help:"Slug of the mode to test."`
+	Paths []string `arg:"" optional:"" help:"Paths to test, relative to the workspace root."`
+	Stdin bool     `help:"Read paths to test from standard input, one per line." default:"false"`
+	Git   bool     `help:"Test all files tracked by git (git ls-files)." default:"false"`
+}
+
+// Run executes the RegexTestCmd
+func (cmd *RegexTestCmd) Run() error {
+	// 1. Collect paths to test
+	paths, err := cmd.collectPaths()
+	if err != nil {
+		return err
+	}
+
+	if len(paths) == 0 {
+		return fmt.Errorf("no paths to test: pass paths as arguments, --stdin or --git")
+	}
+
+	// 2. Load the mode
+	filePath, err := fileutil.GetModeFilePath(cmd.Slug)
+	if err != nil {
+		return err
+	}
+
+	if !fileutil.FileExists(filePath) {
+		return fmt.Errorf("mode not found: %s", cmd.Slug)
+	}
+
+	modeConfig, err := mode.LoadModeFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to parse mode file: %w", err)
+	}
+
+	// 3. Compile the fileRegex of each group
+	regexes := make([]*mode.JSRegex, len(modeConfig.GroupsParsed))
+	for i, group := range modeConfig.GroupsParsed {
+		if group.Options == nil || group.Options.FileRegex == nil {
+			continue
+		}
+
+		re, err := mode.CompileJSRegex(*group.Options.FileRegex)
+		if err != nil {
+			return fmt.Errorf("invalid fileRegex for group %s: %w", group.Name, err)
+		}
+		regexes[i] = re
+	}
+
+	// 4. Report which groups allow each path
+	editable := 0
+	for _, path := range paths {
+		var allowed, denied []string
+
+		for i, group := range modeConfig.GroupsParsed {
+			re := regexes[i]
+			switch {
+			case re == nil:
+				allowed = append(allowed, group.Name)
+			case re.MatchString(path):
+				allowed = append(allowed, fmt.Sprintf("%s (matched fileRegex: %s)", group.Name, re))
+			default:
+				denied = append(denied, fmt.Sprintf("%s (fileRegex: %s)", group.Name, re))
+			}
+
+			if group.Name == mode.GroupEdit && (re == nil || re.MatchString(path)) {
+				editable++
+			}
+		}
+
+		fmt.Println(path)
+		if len(allowed) > 0 {
+			fmt.Printf("   Allowed: %s\n", strings.Join(allowed, ", "))
+		}
+		if len(denied) > 0 {
+			fmt.Printf("   Denied: %s\n", strings.Join(denied, ", "))
+		}
+	}
+
+	// 5. Display summary
+	log.Info(fmt.Sprintf("%d of %d paths can be edited by mode %s", editable, len(paths), cmd.Slug))
+
+	return nil
+}
+
+// collectPaths gathers paths from arguments, standard input and git
+// Paths are made relative to the workspace root and use forward slashes, as RooCode matches
+// workspace-relative paths
+func (cmd *RegexTestCmd) collectPaths() ([]string, error) {
+	paths := append([]string(nil), cmd.Paths...)
+
+	if cmd.Stdin {
+		stdinPaths, err := readLines(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read paths from stdin: %w", err)
+		}
+		paths = append(paths, stdinPaths...)
+	}
+
+	if cmd.Git {
+		out, err := exec.Command("git", "ls-files").Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to run git ls-files: %w", err)
+		}
+		gitPaths, err := readLines(bytes.NewReader(out))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read git ls-files output: %w", err)
+		}
+		paths = append(paths, gitPaths...)
+	}
+
+	root, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	for i, path := range paths {
+		paths[i] = workspacePath(root, path)
+	}
+
+	return paths, nil
+}
+
+// workspacePath returns path relative to the workspace root, with forward slashes
+// Paths outside the workspace are only cleaned.
+func workspacePath(root, path string) string {
+	path = filepath.Clean(path)
+	if filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(root, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// readLines returns the non-empty, trimmed lines of r
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
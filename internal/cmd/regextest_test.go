@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestWorkspacePath(t *testing.T) {
+	root := filepath.FromSlash("/work/project")
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"src/a.ts", "src/a.ts"},
+		{"./src/a.ts", "src/a.ts"},
+		{"src//lib/../a.ts", "src/a.ts"},
+		{filepath.FromSlash("/work/project/src/a.ts"), "src/a.ts"},
+		{filepath.FromSlash("/work/project/../other/a.ts"), "/work/other/a.ts"},
+		{filepath.FromSlash("/work/project-b/a.ts"), "/work/project-b/a.ts"},
+		{filepath.FromSlash("/tmp/a.ts"), "/tmp/a.ts"},
+	}
+
+	for _, tt := range tests {
+		if got := workspacePath(root, tt.path); got != tt.want {
+			t.Errorf("workspacePath(%q, %q) = %q, want %q", root, tt.path, got, tt.want)
+		}
+	}
+}
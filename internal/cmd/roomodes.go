@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of a .roomodes file
+const (
+	RoomodesFormatJSON = "json"
+	RoomodesFormatYAML = "yaml"
+)
+
+// detectRoomodesFormat sniffs the format of .roomodes content
+// JSON documents start with an object or an array, anything else is treated as YAML. YAML in
+// flow style, such as {customModes: [...]}, starts the same way, so content that is only valid
+// YAML is YAML, while content that is neither stays JSON for its error messages.
+func detectRoomodesFormat(data []byte) string {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\ufeff")), " \t\r\n")
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return RoomodesFormatYAML
+	}
+	if !json.Valid(trimmed) {
+		var v interface{}
+		if yaml.Unmarshal(trimmed, &v) == nil {
+			return RoomodesFormatYAML
+		}
+	}
+	return RoomodesFormatJSON
+}
+
+// existingRoomodesFormat returns the format to keep for path
+// It uses the content of an existing file, then the file extension, and falls back to JSON
+func existingRoomodesFormat(path string) string {
+	if data, err := os.ReadFile(path); err == nil && len(bytes.TrimSpace(data)) > 0 {
+		return detectRoomodesFormat(data)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return RoomodesFormatYAML
+	}
+	return RoomodesFormatJSON
+}
+
+// parseRoomodes parses .roomodes content in JSON or YAML
+// A bare list of modes or a single mode object is accepted as well as the customModes object,
+// which is also the format RooCode uses to share a single mode with its rulesFiles
+func parseRoomodes(data []byte) (RoomodesFile, error) {
+	var roomodesFile RoomodesFile
+
+	format := detectRoomodesFormat(data)
+	unmarshal := json.Unmarshal
+	if format == RoomodesFormatYAML {
+		unmarshal = yaml.Unmarshal
+	}
+
+	// Try to parse as a RoomodesFile first (new format)
+	if err := unmarshal(data, &roomodesFile); err != nil {
+		// If that fails, try to parse as a direct array of modes (old format)
+		var modes []ImportedMode
+		if err := unmarshal(data, &modes); err != nil {
+			return RoomodesFile{}, fmt.Errorf("failed to parse %s: %w", strings.ToUpper(format), err)
+		}
+		roomodesFile.CustomModes = modes
+	} else if len(roomodesFile.CustomModes) == 0 {
+		// A document with a single mode at the top level
+		var single ImportedMode
+		if err := unmarshal(data, &single); err == nil && single.Slug != "" {
+			roomodesFile.CustomModes = []ImportedMode{single}
+		}
+	}
+
+	return roomodesFile, nil
+}
+
+// marshalRoomodes encodes v as a .roomodes file in the given format
+func marshalRoomodes(v interface{}, format string) ([]byte, error) {
+	if format == RoomodesFormatYAML {
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(v); err != nil {
+			return nil, fmt.Errorf("failed to marshal YAML: %w", err)
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, fmt.Errorf("failed to marshal YAML: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return data, nil
+}
+
+// jsonToNode decodes a JSON value into a YAML node tree, keeping the order of object keys
+func jsonToNode(data []byte) (*yaml.Node, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decodeJSONNode(decoder)
+}
+
+// decodeJSONNode decodes the next JSON value of decoder into a YAML node tree
+func decodeJSONNode(decoder *json.Decoder) (*yaml.Node, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := token.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if v == '{' {
+			node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		for decoder.More() {
+			if node.Kind == yaml.MappingNode {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string)})
+			}
+			value, err := decodeJSONNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+		// The closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}, nil
+	case json.Number:
+		tag := "!!float"
+		if _, err := v.Int64(); err == nil {
+			tag = "!!int"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}, nil
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+}
+
+// nodeKeys returns the keys of a mapping node, following documents and aliases
+func nodeKeys(node *yaml.Node) map[string]bool {
+	for node != nil && (node.Kind == yaml.DocumentNode || node.Kind == yaml.AliasNode) {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		} else if len(node.Content) > 0 {
+			node = node.Content[0]
+		} else {
+			node = nil
+		}
+	}
+
+	keys := map[string]bool{}
+	if node == nil || node.Kind != yaml.MappingNode {
+		return keys
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keys[node.Content[i].Value] = true
+	}
+	return keys
+}
+
+// nodeToJSON encodes a YAML node tree as JSON, keeping the order of mapping keys
+func nodeToJSON(node *yaml.Node) ([]byte, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return []byte("null"), nil
+		}
+		return nodeToJSON(node.Content[0])
+	case yaml.AliasNode:
+		return nodeToJSON(node.Alias)
+	case yaml.MappingNode, yaml.SequenceNode:
+		open, close := byte('['), byte(']')
+		step := 1
+		if node.Kind == yaml.MappingNode {
+			open, close = '{', '}'
+			step = 2
+		}
+
+		var buf bytes.Buffer
+		buf.WriteByte(open)
+		for i := 0; i+step <= len(node.Content); i += step {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if node.Kind == yaml.MappingNode {
+				key, err := json.Marshal(node.Content[i].Value)
+				if err != nil {
+					return nil, err
+				}
+				buf.Write(key)
+				buf.WriteByte(':')
+			}
+			value, err := nodeToJSON(node.Content[i+step-1])
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(value)
+		}
+		buf.WriteByte(close)
+		return buf.Bytes(), nil
+	}
+
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
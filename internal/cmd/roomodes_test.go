@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/upamune/roomode/internal/mode"
+	"github.com/upamune/roomode/internal/schema"
+)
+
+func TestDetectRoomodesFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "JSON object", data: `{"customModes": []}`, want: RoomodesFormatJSON},
+		{name: "JSON array", data: "\ufeff\n[]", want: RoomodesFormatJSON},
+		{name: "YAML block style", data: "customModes: []\n", want: RoomodesFormatYAML},
+		{name: "YAML flow style object", data: "{customModes: [{slug: test}]}\n", want: RoomodesFormatYAML},
+		{name: "YAML flow style array", data: "[{slug: test, groups: [read]}]\n", want: RoomodesFormatYAML},
+		{name: "invalid JSON", data: "{\"customModes\": [\n<<<<<<< HEAD\n{\"slug\": \"a\"}\n=======\n{\"slug\": \"b\"}\n>>>>>>> b\n]}", want: RoomodesFormatJSON},
+		{name: "empty", data: "", want: RoomodesFormatYAML},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectRoomodesFormat([]byte(tt.data)); got != tt.want {
+				t.Errorf("detectRoomodesFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRoomodesFlowStyleYAML(t *testing.T) {
+	data := "{customModes: [{slug: test, name: Test, roleDefinition: You are Roo, groups: [read, [edit, {fileRegex: '\\.md$'}]]}]}\n"
+
+	roomodesFile, err := parseRoomodes([]byte(data))
+	if err != nil {
+		t.Fatalf("parseRoomodes() error = %v", err)
+	}
+	if len(roomodesFile.CustomModes) != 1 {
+		t.Fatalf("parseRoomodes() = %d modes, want 1", len(roomodesFile.CustomModes))
+	}
+	m := roomodesFile.CustomModes[0]
+	if m.Slug != "test" || m.Name != "Test" || m.RoleDefinition != "You are Roo" || len(m.Groups) != 2 {
+		t.Errorf("parseRoomodes() = %+v, want mode test with 2 groups", m)
+	}
+}
+
+func TestRoomodesSchemaSlugPattern(t *testing.T) {
+	// The schema must accept exactly the slugs the validator accepts
+	s := schema.Generate(RoomodesFile{}, "json", "RooCode .roomodes file")
+	modes, ok := s.Properties["customModes"].Items.(*schema.Schema)
+	if !ok {
+		t.Fatalf("customModes items = %T, want *schema.Schema", s.Properties["customModes"].Items)
+	}
+	slug := modes.Properties["slug"]
+	if slug.Pattern != mode.SlugPattern {
+		t.Errorf("slug pattern = %q, want %q", slug.Pattern, mode.SlugPattern)
+	}
+}
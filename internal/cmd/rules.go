@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/upamune/roomode/internal/fileutil"
+)
+
+// RulesFile is a mode rule file bundled in a .roomodes file
+type RulesFile struct {
+	RelativePath string `yaml:"relativePath" json:"relativePath" jsonschema:"required,minLength=1" jsonschema_description:"Path of the file relative to the .roo directory, e.g. rules-translate/01-style.md"`
+	Content      string `yaml:"content" json:"content" jsonschema:"required" jsonschema_description:"Content of the file"`
+}
+
+// readRulesFiles reads the rule files of a mode from .roo/rules-{slug}
+func readRulesFiles(slug string) ([]RulesFile, error) {
+	files, err := fileutil.ListFiles(fileutil.GetRulesDir(slug))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rules files: %w", err)
+	}
+
+	rulesFiles := make([]RulesFile, 0, len(files))
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rules file: %w", err)
+		}
+
+		rel, err := filepath.Rel(".roo", file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get relative path: %w", err)
+		}
+
+		rulesFiles = append(rulesFiles, RulesFile{
+			RelativePath: filepath.ToSlash(rel),
+			Content:      string(content),
+		})
+	}
+
+	return rulesFiles, nil
+}
+
+// validateRulesFiles checks that all bundled rule files can be written to the mode's rules directory
+func (m ImportedMode) validateRulesFiles() error {
+	for _, rulesFile := range m.RulesFiles {
+		if _, err := rulesFilePath(m.Slug, rulesFile.RelativePath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rulesFilePath returns the path a bundled rule file of a mode is written to
+// Paths are relative to the .roo directory and must stay inside the mode's rules directory
+func rulesFilePath(slug, relativePath string) (string, error) {
+	rel := strings.ReplaceAll(relativePath, "\\", "/")
+	if path.IsAbs(rel) || filepath.IsAbs(relativePath) {
+		return "", fmt.Errorf("rules file path %q must be relative to the .roo directory", relativePath)
+	}
+
+	rel = path.Clean(rel)
+	dir := "rules-" + slug
+	if !strings.HasPrefix(rel, dir+"/") {
+		return "", fmt.Errorf("rules file path %q must be inside %s/", relativePath, dir)
+	}
+
+	return filepath.Join(".roo", filepath.FromSlash(rel)), nil
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/upamune/roomode/internal/schema"
+)
+
+// SchemaCmd is a command to print JSON Schemas for mode files and .roomodes
+type SchemaCmd struct {
+	Kind string `arg:"" optional:"" enum:"frontmatter,roomodes" default:"frontmatter" help:"Schema to print (frontmatter, roomodes)."`
+}
+
+// Run executes the SchemaCmd
+func (cmd *SchemaCmd) Run() error {
+	// 1. Generate the schema from the Go types
+	var s *schema.Schema
+	switch cmd.Kind {
+	case "frontmatter":
+		s = schema.Frontmatter()
+	case "roomodes":
+		s = schema.Generate(RoomodesFile{}, "json", "RooCode .roomodes file")
+	}
+
+	// 2. Print as JSON
+	jsonData, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	fmt.Println(string(jsonData))
+	return nil
+}
@@ -0,0 +1,591 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+	"github.com/mattn/go-isatty"
+
+	"github.com/upamune/roomode/internal/diff"
+	"github.com/upamune/roomode/internal/fileutil"
+	"github.com/upamune/roomode/internal/mode"
+)
+
+// SyncCmd is a command to propagate changes between .roo/modes and .roomodes in both directions
+type SyncCmd struct {
+	RoomodesFile *string `arg:"" optional:"" help:".roomodes JSON or YAML file to sync with (default: .roomodes)."`
+	Strategy     string  `help:"How to resolve modes changed on both sides (${enum}). ask prompts when run in a terminal, markdown keeps .roo/modes and roomodes keeps the .roomodes file." enum:"ask,markdown,roomodes,skip" default:"ask"`
+	Delete       bool    `help:"Remove modes removed on one side from the other side without confirmation. Without it, removals are asked when run in a terminal and skipped otherwise." default:"false"`
+}
+
+// Conflict resolution strategies
+const (
+	SyncStrategyAsk      = "ask"
+	SyncStrategyMarkdown = "markdown"
+	SyncStrategyRoomodes = "roomodes"
+	SyncStrategySkip     = "skip"
+)
+
+// syncStateFile is the file recording the state of the last sync, in the .roo directory
+const syncStateFile = ".roomode-sync.json"
+
+// syncState records the content hash of each mode at the last sync, by slug
+type syncState struct {
+	Version  int               `json:"version"`
+	Roomodes string            `json:"roomodes,omitempty"` // .roomodes file the modes were synced with
+	Modes    map[string]string `json:"modes"`
+}
+
+// syncAction is what sync does with a mode
+type syncAction int
+
+const (
+	syncNone           syncAction = iota // Both sides are the same, or the mode is skipped
+	syncExport                           // Write the mode file to .roomodes
+	syncImport                           // Write the .roomodes entry to the mode file
+	syncDeleteRoomodes                   // Remove the mode from .roomodes
+	syncDeleteMarkdown                   // Remove the mode file
+	syncConflict                         // Both sides changed
+)
+
+// syncMode is a mode as found on both sides of a sync
+type syncMode struct {
+	slug       string
+	file       *mode.Config // Mode file with extends resolved, nil if there is none
+	fileHash   string
+	entry      *ImportedMode // .roomodes entry, nil if there is none
+	entryHash  string
+	syncedHash string // Hash at the last sync, empty if the mode wasn't synced
+	action     syncAction
+}
+
+// Run executes the SyncCmd
+func (cmd *SyncCmd) Run() error {
+	// 1. Load the state of the last sync
+	roomodesPath := ".roomodes"
+	if cmd.RoomodesFile != nil {
+		roomodesPath = *cmd.RoomodesFile
+	}
+
+	statePath := filepath.Join(".roo", syncStateFile)
+	state, err := loadSyncState(statePath)
+	if err != nil {
+		return err
+	}
+
+	// The hashes only describe the file they were recorded for
+	statePathName := filepath.ToSlash(filepath.Clean(roomodesPath))
+	if state.Roomodes != "" && state.Roomodes != statePathName {
+		return fmt.Errorf("%s records a sync with %s, not %s; remove it to sync with another file", statePath, state.Roomodes, roomodesPath)
+	}
+	state.Roomodes = statePathName
+
+	// Without .roomodes, every synced mode would look removed from it, so its modes are
+	// exported again instead of removing the mode files
+	if len(state.Modes) > 0 && !fileutil.FileExists(roomodesPath) {
+		log.Warn(fmt.Sprintf("%s does not exist, exporting all modes again", roomodesPath))
+		state.Modes = map[string]string{}
+	}
+
+	// 2. Load both sides and decide what to do with each mode
+	modes, entries, err := loadSyncModes(roomodesPath, state)
+	if err != nil {
+		return err
+	}
+
+	// 3. Resolve conflicts
+	unresolved := 0
+	for _, m := range modes {
+		if m.action != syncConflict {
+			continue
+		}
+		if err := cmd.resolveConflict(m, roomodesPath); err != nil {
+			return err
+		}
+		if m.action == syncConflict {
+			log.Warn("Mode changed on both sides", "slug", m.slug)
+			unresolved++
+		}
+	}
+
+	// 4. Confirm removals, which are skipped unless allowed
+	if err := cmd.confirmRemovals(modes, roomodesPath); err != nil {
+		return err
+	}
+
+	// 5. Apply the changes to the mode files
+	modesDir, err := fileutil.GetModesDir()
+	if err != nil {
+		return err
+	}
+
+	project, err := currentProject()
+	if err != nil {
+		return err
+	}
+
+	exported, imported, deleted, unchanged, skipped := 0, 0, 0, 0, 0
+	roomodesChanged := false
+	for _, m := range modes {
+		switch m.action {
+		case syncNone:
+			if m.fileHash != m.entryHash {
+				skipped++
+				continue
+			}
+			state.Modes[m.slug] = m.fileHash
+			unchanged++
+		case syncExport:
+			entry, err := newExportedMode(m.file, project, false)
+			if err != nil {
+				return err
+			}
+			if i := indexOfExportedMode(entries, m.slug); i >= 0 {
+				entry.RulesFiles = entries[i].RulesFiles
+				entries[i] = entry
+			} else {
+				entries = append(entries, entry)
+			}
+			roomodesChanged = true
+			state.Modes[m.slug] = m.fileHash
+			log.Info("Exported mode", "slug", m.slug, "file", roomodesPath)
+			exported++
+		case syncImport:
+			if reason := generatedModeReason(m.file); reason != "" {
+				log.Warn(fmt.Sprintf("Mode file uses %s, apply the changes from %s by hand", reason, roomodesPath), "slug", m.slug, "file", displayPath(m.file.FilePath))
+				skipped++
+				continue
+			}
+			filePath, err := writeSyncedModeFile(modesDir, m)
+			if err != nil {
+				return err
+			}
+			state.Modes[m.slug] = m.entryHash
+			log.Info("Imported mode", "slug", m.slug, "file", filePath)
+			imported++
+		case syncDeleteRoomodes:
+			if i := indexOfExportedMode(entries, m.slug); i >= 0 {
+				entries = append(entries[:i], entries[i+1:]...)
+			}
+			roomodesChanged = true
+			delete(state.Modes, m.slug)
+			log.Info("Removed mode", "slug", m.slug, "file", roomodesPath)
+			deleted++
+		case syncDeleteMarkdown:
+			if err := os.Remove(m.file.FilePath); err != nil {
+				return fmt.Errorf("failed to remove mode file: %w", err)
+			}
+			delete(state.Modes, m.slug)
+			log.Info("Removed mode", "slug", m.slug, "file", displayPath(m.file.FilePath))
+			deleted++
+		}
+	}
+
+	// 6. Write .roomodes in its current format, and the new state
+	if roomodesChanged {
+		outputData, err := marshalRoomodes(ExportData{CustomModes: entries}, existingRoomodesFormat(roomodesPath))
+		if err != nil {
+			return err
+		}
+		if err := writeOutputFile(roomodesPath, outputData); err != nil {
+			return err
+		}
+	}
+
+	if err := saveSyncState(statePath, state); err != nil {
+		return err
+	}
+
+	// 7. Display results
+	log.Info(fmt.Sprintf("Sync complete: %d modes exported, %d imported, %d removed, %d unchanged, %d skipped", exported, imported, deleted, unchanged, skipped))
+	if unresolved > 0 {
+		return fmt.Errorf("%d modes changed on both sides were left as they are; resolve them with --strategy", unresolved)
+	}
+
+	return nil
+}
+
+// loadSyncModes loads the mode files and the .roomodes entries and decides what to do with
+// each mode
+// Modes are listed in the order of .roomodes followed by the modes only found in .roo/modes.
+// The entries of .roomodes are returned as they will be written back.
+func loadSyncModes(roomodesPath string, state *syncState) ([]*syncMode, []ExportedMode, error) {
+	var modes []*syncMode
+	bySlug := make(map[string]*syncMode)
+
+	// A missing .roomodes file has no modes yet
+	var roomodesFile RoomodesFile
+	data, err := os.ReadFile(roomodesPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", roomodesPath, err)
+	}
+	if err == nil {
+		roomodesFile, err = parseRoomodes(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", roomodesPath, err)
+		}
+	}
+
+	entries := make([]ExportedMode, 0, len(roomodesFile.CustomModes))
+	for i := range roomodesFile.CustomModes {
+		entry := &roomodesFile.CustomModes[i]
+		modeConfig, err := entry.toModeConfig()
+		if err == nil {
+			err = mode.ValidateMode(modeConfig)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid mode %s in %s: %w", entry.Slug, roomodesPath, err)
+		}
+		if bySlug[entry.Slug] != nil {
+			return nil, nil, fmt.Errorf("duplicate mode %s in %s", entry.Slug, roomodesPath)
+		}
+
+		m := &syncMode{slug: entry.Slug, entry: entry, entryHash: modeHash(modeConfig)}
+		modes = append(modes, m)
+		bySlug[m.slug] = m
+		entries = append(entries, entry.toExportedMode())
+	}
+
+	// Mode files that can't be loaded would look deleted, so they stop the sync
+	files, err := fileutil.ListModeFiles()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list mode files: %w", err)
+	}
+
+	project, err := currentProject()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, file := range files {
+		modeConfig, err := mode.LoadModeFile(file)
+		if err == nil {
+			err = mode.ValidateMode(modeConfig)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid mode file %s: %w", file, err)
+		}
+
+		rendered, err := mode.RenderTemplates(modeConfig, project)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to render templates of mode %s: %w", modeConfig.Slug, err)
+		}
+
+		m := bySlug[modeConfig.Slug]
+		if m == nil {
+			m = &syncMode{slug: modeConfig.Slug}
+			modes = append(modes, m)
+			bySlug[m.slug] = m
+		}
+		m.file = modeConfig
+		m.fileHash = modeHash(rendered)
+	}
+
+	for _, m := range modes {
+		m.syncedHash = state.Modes[m.slug]
+		m.action = decideSyncAction(m)
+	}
+
+	return modes, entries, nil
+}
+
+// decideSyncAction decides how to sync a mode from its hashes on both sides and at the last sync
+// A side that still matches the last sync is unchanged, so the other side's change is applied.
+func decideSyncAction(m *syncMode) syncAction {
+	switch {
+	case m.file != nil && m.entry != nil:
+		switch {
+		case m.fileHash == m.entryHash:
+			return syncNone
+		case m.entryHash == m.syncedHash:
+			return syncExport
+		case m.fileHash == m.syncedHash:
+			return syncImport
+		}
+	case m.file != nil:
+		switch {
+		case m.syncedHash == "":
+			return syncExport
+		case m.fileHash == m.syncedHash:
+			return syncDeleteMarkdown
+		}
+	case m.entry != nil:
+		switch {
+		case m.syncedHash == "":
+			return syncImport
+		case m.entryHash == m.syncedHash:
+			return syncDeleteRoomodes
+		}
+	}
+	return syncConflict
+}
+
+// resolveConflict sets the action of a mode changed on both sides according to the strategy
+// The action is left as syncConflict when the conflict isn't resolved.
+func (cmd *SyncCmd) resolveConflict(m *syncMode, roomodesPath string) error {
+	strategy := cmd.Strategy
+	if strategy == SyncStrategyAsk {
+		if !isInteractive() {
+			return nil
+		}
+
+		var err error
+		strategy, err = askConflictStrategy(m, roomodesPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch strategy {
+	case SyncStrategyMarkdown:
+		if m.file != nil {
+			m.action = syncExport
+		} else {
+			m.action = syncDeleteRoomodes
+		}
+	case SyncStrategyRoomodes:
+		if m.entry != nil {
+			m.action = syncImport
+		} else {
+			m.action = syncDeleteMarkdown
+		}
+	case SyncStrategySkip:
+		log.Info("Skipping mode changed on both sides", "slug", m.slug)
+		m.action = syncNone
+	}
+	return nil
+}
+
+// confirmRemovals asks whether the modes removed on one side should be removed from the other side
+// Unless --delete is set or the removals are confirmed, they are skipped: the mode stays on
+// the other side and in the sync state, so the next sync offers to remove it again.
+func (cmd *SyncCmd) confirmRemovals(modes []*syncMode, roomodesPath string) error {
+	var removals []string
+	for _, m := range modes {
+		switch m.action {
+		case syncDeleteRoomodes:
+			removals = append(removals, fmt.Sprintf("%s from %s", m.slug, roomodesPath))
+		case syncDeleteMarkdown:
+			removals = append(removals, displayPath(m.file.FilePath))
+		}
+	}
+	if len(removals) == 0 || cmd.Delete {
+		return nil
+	}
+
+	confirmed := false
+	if isInteractive() {
+		form := huh.NewForm(
+			huh.NewGroup(
+				huh.NewConfirm().
+					Title(fmt.Sprintf("Remove %d modes removed on the other side?", len(removals))).
+					Description(strings.Join(removals, "\n")).
+					Value(&confirmed),
+			),
+		)
+		if err := form.Run(); err != nil {
+			return fmt.Errorf("form error: %w", err)
+		}
+	}
+	if confirmed {
+		return nil
+	}
+
+	log.Warn(fmt.Sprintf("Skipping %d removals (use --delete to remove them): %s", len(removals), strings.Join(removals, ", ")))
+	for _, m := range modes {
+		if m.action == syncDeleteRoomodes || m.action == syncDeleteMarkdown {
+			m.action = syncNone
+		}
+	}
+	return nil
+}
+
+// askConflictStrategy shows how a mode differs between both sides and asks which one to keep
+func askConflictStrategy(m *syncMode, roomodesPath string) (string, error) {
+	var fileModes, entryModes []*mode.Config
+	fileLabel := filepath.Join(".roo", "modes", m.slug+".md")
+	if m.file != nil {
+		fileModes = append(fileModes, m.file)
+		fileLabel = displayPath(m.file.FilePath)
+	}
+	if m.entry != nil {
+		entryConfig, err := m.entry.toModeConfig()
+		if err != nil {
+			return "", err
+		}
+		entryModes = append(entryModes, entryConfig)
+	}
+
+	fmt.Printf("Mode %s changed in both %s and %s:\n", m.slug, fileLabel, roomodesPath)
+	if err := diff.WriteText(os.Stdout, diff.Modes(entryModes, fileModes), roomodesPath, fileLabel); err != nil {
+		return "", err
+	}
+
+	var strategy string
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title(fmt.Sprintf("Which version of mode '%s' should be kept?", m.slug)).
+				Options(
+					huh.NewOption(fileLabel, SyncStrategyMarkdown),
+					huh.NewOption(roomodesPath, SyncStrategyRoomodes),
+					huh.NewOption("Skip for now", SyncStrategySkip),
+				).
+				Value(&strategy),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return "", fmt.Errorf("form error: %w", err)
+	}
+	return strategy, nil
+}
+
+// writeSyncedModeFile writes the .roomodes entry of a mode to its mode file and returns its path
+// An existing file is updated in place and keeps its frontmatter format.
+func writeSyncedModeFile(modesDir string, m *syncMode) (string, error) {
+	filePath := filepath.Join(modesDir, m.slug+".md")
+	format := mode.FrontmatterYAML
+	var existing []byte
+	if m.file != nil {
+		filePath = m.file.FilePath
+		var err error
+		existing, err = os.ReadFile(filePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read mode file: %w", err)
+		}
+		format = mode.DetectFrontmatterFormat(existing)
+	}
+
+	var content string
+	var err error
+	if existing != nil {
+		if regeneratesFrontmatter(existing, format) {
+			log.Warn("Rewriting the frontmatter from scratch, its comments, key order and unknown keys are not kept", "file", displayPath(filePath))
+		}
+		content, err = UpdateModeMarkdown(existing, *m.entry, format)
+	} else {
+		content, err = GenerateModeMarkdown(*m.entry, format)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to generate markdown for mode %s: %w", m.slug, err)
+	}
+
+	if err := fileutil.WriteFile(filePath, content); err != nil {
+		return "", err
+	}
+	return displayPath(filePath), nil
+}
+
+// generatedModeReason returns why a mode file can't be rewritten from .roomodes, if it can't
+// Modes are exported with extends, templates and includes resolved, so writing them back
+// would replace those with their result.
+func generatedModeReason(m *mode.Config) string {
+	switch {
+	case m == nil:
+		return ""
+	case m.Extends != "":
+		return "extends"
+	case m.IsTemplate():
+		return "templates"
+	case m.Includes:
+		return "includes"
+	}
+	return ""
+}
+
+// modeHash returns a hash of the fields of a mode that are written to .roomodes
+func modeHash(m *mode.Config) string {
+	instructions := ""
+	if m.CustomInstructions != nil {
+		instructions = strings.TrimSpace(*m.CustomInstructions)
+	}
+
+	data, _ := json.Marshal(struct {
+		Name               string
+		Groups             []mode.ParsedGroupEntry
+		RoleDefinition     string
+		WhenToUse          string
+		Description        string
+		Source             string
+		CustomInstructions string
+	}{m.Name, m.GroupsParsed, m.RoleDefinition, m.WhenToUse, m.Description, m.Source, instructions})
+
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// loadSyncState reads the state of the last sync, which is empty before the first sync
+func loadSyncState(path string) (*syncState, error) {
+	state := &syncState{Version: 1, Modes: map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse sync state %s: %w", path, err)
+	}
+	if state.Modes == nil {
+		state.Modes = map[string]string{}
+	}
+	return state, nil
+}
+
+// saveSyncState writes the state of the sync
+func saveSyncState(path string, state *syncState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sync state: %w", err)
+	}
+	return writeOutputFile(path, append(data, '\n'))
+}
+
+// indexOfExportedMode returns the index of the mode with slug in modes, or -1
+func indexOfExportedMode(modes []ExportedMode, slug string) int {
+	for i, m := range modes {
+		if m.Slug == slug {
+			return i
+		}
+	}
+	return -1
+}
+
+// toExportedMode converts a .roomodes entry to the form it is written back in
+// The entry is written back as it was read, including fields roomode doesn't know.
+func (m ImportedMode) toExportedMode() ExportedMode {
+	groups := make([]interface{}, 0, len(m.Groups))
+	for _, g := range m.Groups {
+		groups = append(groups, g)
+	}
+
+	return ExportedMode{
+		Slug:               m.Slug,
+		Name:               m.Name,
+		Groups:             groups,
+		CustomInstructions: m.CustomInstructions,
+		RoleDefinition:     m.RoleDefinition,
+		WhenToUse:          m.WhenToUse,
+		Description:        m.Description,
+		Source:             m.Source,
+		RulesFiles:         m.RulesFiles,
+		raw:                m.raw,
+	}
+}
+
+// isInteractive reports whether standard input is a terminal that can answer prompts
+// It is a variable so that tests can run without prompts.
+var isInteractive = func() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
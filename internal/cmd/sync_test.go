@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/upamune/roomode/internal/fileutil"
+	"github.com/upamune/roomode/internal/mode"
+)
+
+func TestDecideSyncAction(t *testing.T) {
+	file, entry := &mode.Config{}, &ImportedMode{}
+
+	tests := []struct {
+		name string
+		mode syncMode
+		want syncAction
+	}{
+		{"same on both sides", syncMode{file: file, fileHash: "a", entry: entry, entryHash: "a", syncedHash: "b"}, syncNone},
+		{"same on both sides before the first sync", syncMode{file: file, fileHash: "a", entry: entry, entryHash: "a"}, syncNone},
+		{"file changed", syncMode{file: file, fileHash: "b", entry: entry, entryHash: "a", syncedHash: "a"}, syncExport},
+		{"entry changed", syncMode{file: file, fileHash: "a", entry: entry, entryHash: "b", syncedHash: "a"}, syncImport},
+		{"both changed", syncMode{file: file, fileHash: "b", entry: entry, entryHash: "c", syncedHash: "a"}, syncConflict},
+		{"different before the first sync", syncMode{file: file, fileHash: "a", entry: entry, entryHash: "b"}, syncConflict},
+		{"file added", syncMode{file: file, fileHash: "a"}, syncExport},
+		{"entry added", syncMode{entry: entry, entryHash: "a"}, syncImport},
+		{"entry removed", syncMode{file: file, fileHash: "a", syncedHash: "a"}, syncDeleteMarkdown},
+		{"file removed", syncMode{entry: entry, entryHash: "a", syncedHash: "a"}, syncDeleteRoomodes},
+		{"entry removed after the file changed", syncMode{file: file, fileHash: "b", syncedHash: "a"}, syncConflict},
+		{"file removed after the entry changed", syncMode{entry: entry, entryHash: "b", syncedHash: "a"}, syncConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decideSyncAction(&tt.mode); got != tt.want {
+				t.Errorf("decideSyncAction() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// syncedWorkspace sets up a workspace with two modes synced with .roomodes
+func syncedWorkspace(t *testing.T) {
+	t.Helper()
+	interactive := isInteractive
+	isInteractive = func() bool { return false }
+	t.Cleanup(func() { isInteractive = interactive })
+
+	setupWorkspace(t, map[string]string{
+		".roo/modes/a.md": "---\nname: A\nroleDefinition: You are Roo\ngroups: [read]\n---\n",
+		".roo/modes/b.md": "---\nname: B\nroleDefinition: You are Roo\ngroups: [read]\n---\n",
+	})
+	if err := (&SyncCmd{Strategy: SyncStrategyAsk}).Run(); err != nil {
+		t.Fatalf("first sync error = %v", err)
+	}
+	if !fileutil.FileExists(".roomodes") {
+		t.Fatal("first sync didn't write .roomodes")
+	}
+}
+
+func TestSyncRemovals(t *testing.T) {
+	tests := []struct {
+		name       string
+		remove     func(t *testing.T)
+		delete     bool
+		wantFileA  bool
+		wantEntryA bool
+	}{
+		{
+			name:       "entry removed without --delete",
+			remove:     removeRoomodesEntry,
+			wantFileA:  true,
+			wantEntryA: false,
+		},
+		{
+			name:       "entry removed with --delete",
+			remove:     removeRoomodesEntry,
+			delete:     true,
+			wantFileA:  false,
+			wantEntryA: false,
+		},
+		{
+			name:       "file removed without --delete",
+			remove:     removeModeFile,
+			wantFileA:  false,
+			wantEntryA: true,
+		},
+		{
+			name:       "file removed with --delete",
+			remove:     removeModeFile,
+			delete:     true,
+			wantFileA:  false,
+			wantEntryA: false,
+		},
+		{
+			name: ".roomodes removed",
+			remove: func(t *testing.T) {
+				if err := os.Remove(".roomodes"); err != nil {
+					t.Fatal(err)
+				}
+			},
+			delete:     true,
+			wantFileA:  true,
+			wantEntryA: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			syncedWorkspace(t)
+			tt.remove(t)
+
+			if err := (&SyncCmd{Strategy: SyncStrategyAsk, Delete: tt.delete}).Run(); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if got := fileutil.FileExists(".roo/modes/a.md"); got != tt.wantFileA {
+				t.Errorf("a.md exists = %t, want %t", got, tt.wantFileA)
+			}
+			if got := strings.Contains(readFile(t, ".roomodes"), `"slug": "a"`); got != tt.wantEntryA {
+				t.Errorf(".roomodes lists a = %t, want %t", got, tt.wantEntryA)
+			}
+			if !fileutil.FileExists(".roo/modes/b.md") || !strings.Contains(readFile(t, ".roomodes"), `"slug": "b"`) {
+				t.Error("mode b was removed")
+			}
+		})
+	}
+}
+
+// removeRoomodesEntry removes mode a from .roomodes
+func removeRoomodesEntry(t *testing.T) {
+	t.Helper()
+	data := `{"customModes": [{"slug": "b", "name": "B", "roleDefinition": "You are Roo", "groups": ["read"]}]}`
+	if err := os.WriteFile(".roomodes", []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// removeModeFile removes the mode file of mode a
+func removeModeFile(t *testing.T) {
+	t.Helper()
+	if err := os.Remove(filepath.FromSlash(".roo/modes/a.md")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncRoomodesPathChanged(t *testing.T) {
+	syncedWorkspace(t)
+
+	other := "other.json"
+	err := (&SyncCmd{RoomodesFile: &other, Strategy: SyncStrategyAsk}).Run()
+	if err == nil || !strings.Contains(err.Error(), "records a sync with .roomodes, not other.json") {
+		t.Errorf("Run() error = %v, want an error about the recorded .roomodes path", err)
+	}
+	if fileutil.FileExists(other) {
+		t.Errorf("sync wrote %s", other)
+	}
+}
+
+func TestSyncKeepsUnknownFields(t *testing.T) {
+	syncedWorkspace(t)
+
+	// Fields roomode doesn't know don't change the entry, so b is only written back
+	data := `{"customModes": [
+  {"slug": "a", "name": "A", "roleDefinition": "You are Roo", "groups": ["read"]},
+  {"slug": "b", "name": "B", "roleDefinition": "You are Roo", "groups": ["read"], "custom": {"z": 1, "y": [true]}}
+]}`
+	if err := os.WriteFile(".roomodes", []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.FromSlash(".roo/modes/a.md"), []byte("---\nname: A2\nroleDefinition: You are Roo\ngroups: [read]\n---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (&SyncCmd{Strategy: SyncStrategyAsk}).Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	got := readFile(t, ".roomodes")
+	if !strings.Contains(got, `"name": "A2"`) {
+		t.Errorf(".roomodes = %s, want mode a to be exported", got)
+	}
+	want := `      "custom": {
+        "z": 1,
+        "y": [
+          true
+        ]
+      }`
+	if !strings.Contains(got, want) {
+		t.Errorf(".roomodes = %s, want the unknown fields of b to be kept", got)
+	}
+}
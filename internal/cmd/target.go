@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/upamune/roomode/internal/mode"
+)
+
+// ExportTarget describes a tool that custom modes can be exported to
+type ExportTarget interface {
+	// Name is the name of the target, as given to --target
+	Name() string
+	// DefaultPath is the file the target reads project modes from
+	DefaultPath() string
+	// Validate checks that the target supports a mode that is already valid for roomode
+	Validate(m *mode.Config) error
+	// Filter removes the fields of a mode that the target doesn't read
+	Filter(m ExportedMode) ExportedMode
+}
+
+// Names of the built-in export targets
+const (
+	TargetRoo      = "roo"
+	TargetKiloCode = "kilocode"
+)
+
+// exportTargets lists the built-in export targets by name
+var exportTargets = map[string]ExportTarget{
+	TargetRoo: &modesFileTarget{
+		name:        TargetRoo,
+		defaultPath: ".roomodes",
+		groups:      mode.ToolGroups,
+	},
+	// Kilo Code is a fork of RooCode and reads the same schema from its own file
+	TargetKiloCode: &modesFileTarget{
+		name:        TargetKiloCode,
+		defaultPath: ".kilocodemodes",
+		groups:      kiloCodeGroups,
+	},
+}
+
+// kiloCodeGroups lists the tool groups Kilo Code accepts, it has no browser group
+var kiloCodeGroups = []string{mode.GroupRead, mode.GroupEdit, mode.GroupCommand, mode.GroupMCP}
+
+// lookupExportTarget returns the built-in export target called name
+func lookupExportTarget(name string) (ExportTarget, error) {
+	target, ok := exportTargets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown export target: %s", name)
+	}
+	return target, nil
+}
+
+// modesFileTarget is a target that reads a RooCode style custom modes file
+type modesFileTarget struct {
+	name        string
+	defaultPath string
+	groups      []string // Tool groups the target accepts
+	// omitFields lists the optional fields the target doesn't read, by their file key
+	omitFields []string
+}
+
+// Name returns the name of the target
+func (t *modesFileTarget) Name() string {
+	return t.name
+}
+
+// DefaultPath returns the file the target reads project modes from
+func (t *modesFileTarget) DefaultPath() string {
+	return t.defaultPath
+}
+
+// Validate checks that every group of the mode is accepted by the target
+func (t *modesFileTarget) Validate(m *mode.Config) error {
+	var diags mode.Diagnostics
+	for i, group := range m.GroupsParsed {
+		if slices.Contains(t.groups, group.Name) {
+			continue
+		}
+		field := fmt.Sprintf("groups[%d]", i)
+		pos := m.Position(field)
+		diags = append(diags, mode.Diagnostic{
+			File:    m.FilePath,
+			Line:    pos.Line,
+			Column:  pos.Column,
+			Field:   field,
+			Message: fmt.Sprintf("group %q is not supported by %s (supported groups: %s)", group.Name, t.name, strings.Join(t.groups, ", ")),
+		})
+	}
+	if len(diags) > 0 {
+		return diags
+	}
+	return nil
+}
+
+// Filter clears the optional fields the target doesn't read
+func (t *modesFileTarget) Filter(m ExportedMode) ExportedMode {
+	for _, field := range t.omitFields {
+		switch field {
+		case "customInstructions":
+			m.CustomInstructions = nil
+		case "whenToUse":
+			m.WhenToUse = ""
+		case "description":
+			m.Description = ""
+		case "source":
+			m.Source = ""
+		case "rulesFiles":
+			m.RulesFiles = nil
+		}
+	}
+	return m
+}
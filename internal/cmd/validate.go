@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/upamune/roomode/internal/mode"
+)
+
+// ValidateCmd is a command to validate mode files and report every problem found
+type ValidateCmd struct {
+	Paths  []string `arg:"" optional:"" help:"Mode files or directories to validate (default: .roo/modes)."`
+	Format string   `short:"f" enum:"text,github,sarif" default:"text" help:"Output format (text, github, sarif)."`
+}
+
+// Run executes the ValidateCmd
+func (cmd *ValidateCmd) Run() error {
+	// 1. Collect mode files to validate
+	files, err := collectModeFiles(cmd.Paths)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		log.Info("No custom modes found to validate")
+		return nil
+	}
+
+	// 2. Parse and validate each file, collecting all diagnostics
+	var diags mode.Diagnostics
+	invalidFiles := 0
+
+	for _, file := range files {
+		fileDiags := checkModeFile(file)
+		if len(fileDiags) > 0 {
+			invalidFiles++
+			diags = append(diags, fileDiags...)
+		}
+	}
+
+	// 3. Print diagnostics
+	if err := writeDiagnostics(cmd.Format, diags); err != nil {
+		return err
+	}
+
+	// 4. Display results
+	if len(diags) > 0 {
+		return fmt.Errorf("validation failed: %d problems in %d of %d files", len(diags), invalidFiles, len(files))
+	}
+
+	log.Info(fmt.Sprintf("Validation passed: %d mode files checked", len(files)))
+	return nil
+}
+
+// checkModeFile parses and validates a mode file and returns every problem found
+// A mode that was only partially parsed is still validated
+func checkModeFile(file string) mode.Diagnostics {
+	var diags mode.Diagnostics
+	modeConfig, err := mode.LoadModeFile(file)
+	if err != nil {
+		diags = mode.AsDiagnostics(file, err)
+	}
+	if modeConfig == nil {
+		return diags
+	}
+
+	if err := mode.ValidateMode(modeConfig); err != nil {
+		diags = append(diags, mode.AsDiagnostics(file, err)...)
+	}
+
+	return diags
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestCheckModeFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name: "valid mode",
+			content: `---
+name: Test
+roleDefinition: You are Roo
+groups: [read]
+---
+`,
+		},
+		{
+			name: "invalid groups don't hide other problems",
+			content: `---
+roleDefinition: ""
+groups:
+  - read
+  - read
+  - [edit]
+  - browse
+  - [edit, {fileRegex: "("}]
+---
+`,
+			want: []string{
+				"5:5: duplicate group \"read\" at index 1 (already listed at index 0)",
+				"6:5: invalid group entry at index 2: array must have exactly 2 elements",
+				"1:1: name is required",
+				"7:5: unknown group \"browse\" at index 3 (did you mean \"browser\"?)",
+				"8:24: invalid fileRegex at index 4: position 1: unterminated group",
+				"2:17: role definition (markdown content) is required",
+			},
+		},
+		{
+			name: "unknown keys don't hide other problems",
+			content: `---
+name: Test
+roleDefiniton: You are Roo
+groups: [read, browse]
+---
+`,
+			want: []string{
+				"3:1: unknown frontmatter key \"roleDefiniton\" (did you mean \"roleDefinition\"?)",
+				"4:16: unknown group \"browse\" at index 1 (did you mean \"browser\"?)",
+				"1:1: role definition (markdown content) is required",
+			},
+		},
+		{
+			name: "only invalid groups",
+			content: `---
+name: Test
+roleDefinition: You are Roo
+groups: [1]
+---
+`,
+			want: []string{"4:10: invalid group entry at index 0: must be a string, an array, or a map, got int"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupWorkspace(t, map[string]string{".roo/modes/test.md": tt.content})
+
+			var got []string
+			for _, d := range checkModeFile(".roo/modes/test.md") {
+				got = append(got, fmt.Sprintf("%d:%d: %s", d.Line, d.Column, d.Message))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checkModeFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op is the kind of a line edit
+type Op int
+
+// Line edit operations
+const (
+	Equal Op = iota
+	Delete
+	Insert
+)
+
+// Edit is one line of a line diff
+type Edit struct {
+	Op   Op
+	Line string
+}
+
+// contextLines is the number of unchanged lines shown around changes in unified diffs
+const contextLines = 3
+
+// Lines returns the shortest list of edits that turns a into b, using the linear space variant
+// of Myers' algorithm, which splits the texts at the middle of the shortest edit path
+// Within a run of changes, deleted lines come before inserted lines.
+func Lines(a, b []string) []Edit {
+	// Lines are compared as integers
+	ids := make(map[string]int, len(a)+len(b))
+	lineIDs := func(lines []string) []int {
+		result := make([]int, len(lines))
+		for i, line := range lines {
+			id, ok := ids[line]
+			if !ok {
+				id = len(ids)
+				ids[line] = id
+			}
+			result[i] = id
+		}
+		return result
+	}
+
+	d := &differ{a: a, b: b, aIDs: lineIDs(a), bIDs: lineIDs(b)}
+	d.compare(0, len(a), 0, len(b))
+	return groupChanges(d.edits)
+}
+
+// differ computes the edits between a and b
+type differ struct {
+	a, b       []string
+	aIDs, bIDs []int
+	edits      []Edit
+}
+
+// compare appends the edits that turn a[aLo:aHi] into b[bLo:bHi]
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	// Lines shared at the start and end need no search
+	prefix := 0
+	for aLo+prefix < aHi && bLo+prefix < bHi && d.aIDs[aLo+prefix] == d.bIDs[bLo+prefix] {
+		prefix++
+	}
+	for i := 0; i < prefix; i++ {
+		d.edits = append(d.edits, Edit{Op: Equal, Line: d.a[aLo+i]})
+	}
+	aLo, bLo = aLo+prefix, bLo+prefix
+
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.aIDs[aHi-suffix-1] == d.bIDs[bHi-suffix-1] {
+		suffix++
+	}
+	aHi, bHi = aHi-suffix, bHi-suffix
+
+	switch {
+	case aLo == aHi:
+		for _, line := range d.b[bLo:bHi] {
+			d.edits = append(d.edits, Edit{Op: Insert, Line: line})
+		}
+	case bLo == bHi:
+		for _, line := range d.a[aLo:aHi] {
+			d.edits = append(d.edits, Edit{Op: Delete, Line: line})
+		}
+	default:
+		x, y := d.middle(aLo, aHi, bLo, bHi)
+		d.compare(aLo, x, bLo, y)
+		d.compare(x, aHi, y, bHi)
+	}
+
+	for _, line := range d.a[aHi : aHi+suffix] {
+		d.edits = append(d.edits, Edit{Op: Equal, Line: line})
+	}
+}
+
+// middle returns a point on the shortest edit path from the start to the end of
+// a[aLo:aHi] and b[bLo:bHi], found by searching forward and backward at the same time
+// until the paths overlap. Both ranges must be non-empty.
+func (d *differ) middle(aLo, aHi, bLo, bHi int) (int, int) {
+	a, b := d.aIDs[aLo:aHi], d.bIDs[bLo:bHi]
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD
+
+	// forward[offset+k] is the furthest x reached on diagonal k = x-y from the start, and
+	// backward[offset+k] the furthest distance from the end reached on diagonal k from the end
+	forward := make([]int, 2*maxD+2)
+	backward := make([]int, 2*maxD+2)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+
+	delta := n - m
+	// With an odd delta the forward search meets the backward search, otherwise the reverse
+	odd := delta%2 != 0
+
+	// Diagonals that ran off the edges of the edit graph are skipped
+	fStart, fEnd, bStart, bEnd := 0, 0, 0, 0
+	for step := 0; step < maxD; step++ {
+		for k := -step + fStart; k <= step-fEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -step || (k != step && forward[i-1] < forward[i+1]) {
+				x = forward[i+1]
+			} else {
+				x = forward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[i] = x
+
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				j := offset + delta - k
+				if j >= 0 && j < len(backward) && backward[j] != -1 && x >= n-backward[j] {
+					return aLo + x, bLo + y
+				}
+			}
+		}
+
+		for k := -step + bStart; k <= step-bEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -step || (k != step && backward[i-1] < backward[i+1]) {
+				x = backward[i+1]
+			} else {
+				x = backward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x++
+				y++
+			}
+			backward[i] = x
+
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !odd:
+				j := offset + delta - k
+				if j >= 0 && j < len(forward) && forward[j] != -1 && forward[j] >= n-x {
+					fx := forward[j]
+					return aLo + fx, bLo + fx - (j - offset)
+				}
+			}
+		}
+	}
+
+	// The texts have nothing in common
+	return aHi, bLo
+}
+
+// groupChanges reorders each run of changes so that its deleted lines come first
+func groupChanges(edits []Edit) []Edit {
+	result := make([]Edit, 0, len(edits))
+	for i := 0; i < len(edits); {
+		if edits[i].Op == Equal {
+			result = append(result, edits[i])
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(edits) && edits[j].Op != Equal {
+			j++
+		}
+		for _, op := range []Op{Delete, Insert} {
+			for _, e := range edits[i:j] {
+				if e.Op == op {
+					result = append(result, e)
+				}
+			}
+		}
+		i = j
+	}
+	return result
+}
+
+// Unified returns a unified diff of two texts, or an empty string if they are equal
+func Unified(oldName, newName, oldText, newText string) string {
+	edits := Lines(splitLines(oldText), splitLines(newText))
+
+	var buf strings.Builder
+	for _, h := range hunks(edits) {
+		if buf.Len() == 0 {
+			fmt.Fprintf(&buf, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(h.oldStart, h.oldCount), hunkRange(h.newStart, h.newCount))
+		for _, e := range h.edits {
+			switch e.Op {
+			case Equal:
+				buf.WriteString(" ")
+			case Delete:
+				buf.WriteString("-")
+			case Insert:
+				buf.WriteString("+")
+			}
+			buf.WriteString(e.Line)
+			buf.WriteString("\n")
+		}
+	}
+	return buf.String()
+}
+
+// hunk is a group of nearby changes with their surrounding context
+type hunk struct {
+	oldStart, oldCount int
+	newStart, newCount int
+	edits              []Edit
+}
+
+// hunks groups edits into hunks, merging changes separated by little context
+func hunks(edits []Edit) []hunk {
+	var result []hunk
+
+	// Line numbers in the old and new text before each edit
+	oldLine := make([]int, len(edits)+1)
+	newLine := make([]int, len(edits)+1)
+	for i, e := range edits {
+		oldLine[i+1], newLine[i+1] = oldLine[i], newLine[i]
+		if e.Op != Insert {
+			oldLine[i+1]++
+		}
+		if e.Op != Delete {
+			newLine[i+1]++
+		}
+	}
+
+	for i := 0; i < len(edits); {
+		if edits[i].Op == Equal {
+			i++
+			continue
+		}
+
+		start := i - contextLines
+		if start < 0 {
+			start = 0
+		}
+
+		// Extend the hunk while at most 2*contextLines equal lines separate it from the next change,
+		// as diff -u does
+		last := i
+		for j := i + 1; j < len(edits) && j-last <= 2*contextLines+1; j++ {
+			if edits[j].Op != Equal {
+				last = j
+			}
+		}
+		end := last + contextLines + 1
+		if end > len(edits) {
+			end = len(edits)
+		}
+
+		h := hunk{
+			oldStart: oldLine[start] + 1,
+			oldCount: oldLine[end] - oldLine[start],
+			newStart: newLine[start] + 1,
+			newCount: newLine[end] - newLine[start],
+			edits:    edits[start:end],
+		}
+		// An empty range starts at the line before it
+		if h.oldCount == 0 {
+			h.oldStart--
+		}
+		if h.newCount == 0 {
+			h.newStart--
+		}
+		result = append(result, h)
+		i = end
+	}
+
+	return result
+}
+
+// hunkRange formats the line range of a hunk
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits text into lines, ignoring a final newline
+func splitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,231 @@
+package diff
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// numbered returns the lines "1" to "n"
+func numbered(n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprint(i + 1)
+	}
+	return lines
+}
+
+// replaced returns lines with the given 1-based line numbers replaced
+func replaced(lines []string, replacements map[int]string) string {
+	result := append([]string(nil), lines...)
+	for n, line := range replacements {
+		result[n-1] = line
+	}
+	return strings.Join(result, "\n") + "\n"
+}
+
+// formatEdits formats edits as diff lines for test failure messages
+func formatEdits(edits []Edit) string {
+	var buf strings.Builder
+	for _, e := range edits {
+		buf.WriteString([]string{" ", "-", "+"}[e.Op] + e.Line + "\n")
+	}
+	return buf.String()
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"equal", "a b c", "a b c", " a\n b\n c\n"},
+		{"both empty", "", "", ""},
+		{"insert into empty", "", "a b", "+a\n+b\n"},
+		{"delete all", "a b", "", "-a\n-b\n"},
+		{"replace all", "a b", "c d", "-a\n-b\n+c\n+d\n"},
+		{"insert in the middle", "a c", "a b c", " a\n+b\n c\n"},
+		{"delete in the middle", "a b c", "a c", " a\n-b\n c\n"},
+		{"change one line", "a b c", "a x c", " a\n-b\n+x\n c\n"},
+		{"move a line", "a b c d", "b c d a", "-a\n b\n c\n d\n+a\n"},
+		{"deletions before insertions", "a b c d e", "a x y e", " a\n-b\n-c\n-d\n+x\n+y\n e\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatEdits(Lines(strings.Fields(tt.a), strings.Fields(tt.b)))
+			if got != tt.want {
+				t.Errorf("Lines(%q, %q) =\n%s\nwant\n%s", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinesShortest(t *testing.T) {
+	// Random texts over a small alphabet have many equal lines to choose from
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		a, b := randomLines(r), randomLines(r)
+		edits := Lines(a, b)
+
+		var gotA, gotB []string
+		changes := 0
+		for _, e := range edits {
+			if e.Op != Insert {
+				gotA = append(gotA, e.Line)
+			}
+			if e.Op != Delete {
+				gotB = append(gotB, e.Line)
+			}
+			if e.Op != Equal {
+				changes++
+			}
+		}
+		if strings.Join(gotA, " ") != strings.Join(a, " ") || strings.Join(gotB, " ") != strings.Join(b, " ") {
+			t.Fatalf("Lines(%q, %q) =\n%s\ndoesn't turn a into b", a, b, formatEdits(edits))
+		}
+		if want := len(a) + len(b) - 2*lcsLength(a, b); changes != want {
+			t.Fatalf("Lines(%q, %q) has %d changes, want %d", a, b, changes, want)
+		}
+	}
+}
+
+// randomLines returns up to 15 lines from a small alphabet
+func randomLines(r *rand.Rand) []string {
+	lines := make([]string, r.Intn(16))
+	for i := range lines {
+		lines[i] = string(rune('a' + r.Intn(4)))
+	}
+	return lines
+}
+
+// lcsLength returns the length of the longest common subsequence of a and b
+func lcsLength(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] > cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func TestLinesLargeRewrite(t *testing.T) {
+	a, b := make([]string, 4000), make([]string, 4000)
+	for i := range a {
+		a[i], b[i] = fmt.Sprint("old ", i), fmt.Sprint("new ", i)
+	}
+
+	edits := Lines(a, b)
+	if len(edits) != 8000 || edits[0].Op != Delete || edits[4000].Op != Insert {
+		t.Errorf("Lines() returned %d edits, want 4000 deletions followed by 4000 insertions", len(edits))
+	}
+}
+
+func TestUnified(t *testing.T) {
+	lines := numbered(12)
+	old := strings.Join(lines, "\n") + "\n"
+
+	// The expected diffs are the output of diff -u
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want string
+	}{
+		{
+			name: "equal",
+			old:  old,
+			new:  old,
+			want: "",
+		},
+		{
+			name: "changes separated by 2*contextLines equal lines share a hunk",
+			old:  old,
+			new:  replaced(lines, map[int]string{2: "X", 9: "Y"}),
+			want: `@@ -1,12 +1,12 @@
+ 1
+-2
++X
+ 3
+ 4
+ 5
+ 6
+ 7
+ 8
+-9
++Y
+ 10
+ 11
+ 12
+`,
+		},
+		{
+			name: "changes separated by more equal lines get their own hunks",
+			old:  old,
+			new:  replaced(lines, map[int]string{2: "X", 10: "Y"}),
+			want: `@@ -1,5 +1,5 @@
+ 1
+-2
++X
+ 3
+ 4
+ 5
+@@ -7,6 +7,6 @@
+ 7
+ 8
+ 9
+-10
++Y
+ 11
+ 12
+`,
+		},
+		{
+			name: "replace all",
+			old:  "a\nb\n",
+			new:  "c\n",
+			want: "@@ -1,2 +1 @@\n-a\n-b\n+c\n",
+		},
+		{
+			name: "from empty",
+			old:  "",
+			new:  "c\n",
+			want: "@@ -0,0 +1 @@\n+c\n",
+		},
+		{
+			name: "to empty",
+			old:  "a\nb\n",
+			new:  "",
+			want: "@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name: "CRLF line endings",
+			old:  "a\r\nb\r\n",
+			new:  "a\nc\n",
+			want: "@@ -1,2 +1,2 @@\n a\n-b\n+c\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.want
+			if want != "" {
+				want = "--- old\n+++ new\n" + want
+			}
+			if got := Unified("old", "new", tt.old, tt.new); got != want {
+				t.Errorf("Unified() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
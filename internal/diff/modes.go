@@ -0,0 +1,152 @@
+package diff
+
+import (
+	"strings"
+
+	"github.com/upamune/roomode/internal/mode"
+)
+
+// Kind is the kind of change made to a mode
+type Kind string
+
+// Kinds of mode changes
+const (
+	Added   Kind = "added"
+	Removed Kind = "removed"
+	Changed Kind = "changed"
+)
+
+// FieldChange is a change to one field of a mode
+// Fields of groups are named after the group, e.g. "groups.edit.fileRegex".
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+	Text  bool // Multi-line text, shown as a line diff
+}
+
+// ModeChange describes how a mode differs between two sets of modes
+type ModeChange struct {
+	Slug   string
+	Kind   Kind
+	Fields []FieldChange // Set for changed modes
+}
+
+// Modes compares two sets of modes by slug
+// Changed and removed modes are listed in the order of old, followed by added modes in the
+// order of new. Modes that are the same in both sets are left out.
+func Modes(old, new []*mode.Config) []ModeChange {
+	newBySlug := make(map[string]*mode.Config, len(new))
+	for _, m := range new {
+		newBySlug[m.Slug] = m
+	}
+	oldSlugs := make(map[string]bool, len(old))
+
+	var changes []ModeChange
+	for _, o := range old {
+		oldSlugs[o.Slug] = true
+		n, ok := newBySlug[o.Slug]
+		if !ok {
+			changes = append(changes, ModeChange{Slug: o.Slug, Kind: Removed})
+			continue
+		}
+		if fields := compareMode(o, n); len(fields) > 0 {
+			changes = append(changes, ModeChange{Slug: o.Slug, Kind: Changed, Fields: fields})
+		}
+	}
+
+	for _, n := range new {
+		if !oldSlugs[n.Slug] {
+			changes = append(changes, ModeChange{Slug: n.Slug, Kind: Added})
+		}
+	}
+
+	return changes
+}
+
+// compareMode returns the fields that differ between two versions of a mode
+func compareMode(old, new *mode.Config) []FieldChange {
+	var fields []FieldChange
+	compare := func(field, o, n string, text bool) {
+		if o != n {
+			fields = append(fields, FieldChange{Field: field, Old: o, New: n, Text: text})
+		}
+	}
+
+	compare("name", old.Name, new.Name, false)
+	fields = append(fields, compareGroups(old.GroupsParsed, new.GroupsParsed)...)
+	compare("roleDefinition", old.RoleDefinition, new.RoleDefinition, true)
+	compare("whenToUse", old.WhenToUse, new.WhenToUse, true)
+	compare("description", old.Description, new.Description, false)
+	compare("source", old.Source, new.Source, false)
+	compare("customInstructions", instructions(old), instructions(new), true)
+
+	return fields
+}
+
+// compareGroups returns the changes to the list of groups and to the options of groups
+// listed on both sides
+func compareGroups(old, new []mode.ParsedGroupEntry) []FieldChange {
+	var fields []FieldChange
+
+	oldNames, newNames := groupNames(old), groupNames(new)
+	if strings.Join(oldNames, ",") != strings.Join(newNames, ",") {
+		fields = append(fields, FieldChange{
+			Field: "groups",
+			Old:   strings.Join(oldNames, ", "),
+			New:   strings.Join(newNames, ", "),
+		})
+	}
+
+	newByName := make(map[string]mode.ParsedGroupEntry, len(new))
+	for _, g := range new {
+		newByName[g.Name] = g
+	}
+	for _, o := range old {
+		n, ok := newByName[o.Name]
+		if !ok {
+			continue
+		}
+		oldRegex, oldDescription := groupOptions(o)
+		newRegex, newDescription := groupOptions(n)
+		if oldRegex != newRegex {
+			fields = append(fields, FieldChange{Field: "groups." + o.Name + ".fileRegex", Old: oldRegex, New: newRegex})
+		}
+		if oldDescription != newDescription {
+			fields = append(fields, FieldChange{Field: "groups." + o.Name + ".description", Old: oldDescription, New: newDescription})
+		}
+	}
+
+	return fields
+}
+
+// groupNames returns the names of groups in order
+func groupNames(groups []mode.ParsedGroupEntry) []string {
+	names := make([]string, 0, len(groups))
+	for _, g := range groups {
+		names = append(names, g.Name)
+	}
+	return names
+}
+
+// groupOptions returns the fileRegex and description of a group, empty when unset
+func groupOptions(g mode.ParsedGroupEntry) (fileRegex, description string) {
+	if g.Options == nil {
+		return "", ""
+	}
+	if g.Options.FileRegex != nil {
+		fileRegex = *g.Options.FileRegex
+	}
+	if g.Options.Description != nil {
+		description = *g.Options.Description
+	}
+	return fileRegex, description
+}
+
+// instructions returns the custom instructions of a mode without surrounding whitespace
+func instructions(m *mode.Config) string {
+	if m.CustomInstructions == nil {
+		return ""
+	}
+	return strings.TrimSpace(*m.CustomInstructions)
+}
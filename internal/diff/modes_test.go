@@ -0,0 +1,125 @@
+package diff
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/upamune/roomode/internal/mode"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestModes(t *testing.T) {
+	base := func(slug string) *mode.Config {
+		return &mode.Config{
+			Slug:           slug,
+			Name:           "Mode " + slug,
+			RoleDefinition: "You are Roo",
+			GroupsParsed: []mode.ParsedGroupEntry{
+				{Name: "read"},
+				{Name: "edit", Options: &mode.GroupOptions{FileRegex: strPtr(`\.md$`)}},
+			},
+		}
+	}
+	changed := base("b")
+	changed.Name = "Renamed"
+	changed.GroupsParsed = []mode.ParsedGroupEntry{
+		{Name: "read"},
+		{Name: "edit", Options: &mode.GroupOptions{FileRegex: strPtr(`\.go$`), Description: strPtr("Go")}},
+		{Name: "command"},
+	}
+	changed.CustomInstructions = strPtr("  Test first\n")
+
+	// Surrounding whitespace of instructions isn't a change
+	padded := base("c")
+	padded.CustomInstructions = strPtr("\nSame\n\n")
+	trimmed := base("c")
+	trimmed.CustomInstructions = strPtr("Same")
+
+	old := []*mode.Config{base("a"), base("b"), padded}
+	new := []*mode.Config{base("d"), trimmed, changed}
+
+	want := []ModeChange{
+		{Slug: "a", Kind: Removed},
+		{Slug: "b", Kind: Changed, Fields: []FieldChange{
+			{Field: "name", Old: "Mode b", New: "Renamed"},
+			{Field: "groups", Old: "read, edit", New: "read, edit, command"},
+			{Field: "groups.edit.fileRegex", Old: `\.md$`, New: `\.go$`},
+			{Field: "groups.edit.description", Old: "", New: "Go"},
+			{Field: "customInstructions", Old: "", New: "Test first", Text: true},
+		}},
+		{Slug: "d", Kind: Added},
+	}
+
+	if got := Modes(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("Modes() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestModesEqual(t *testing.T) {
+	m := &mode.Config{Slug: "a", Name: "A", RoleDefinition: "You are Roo", GroupsParsed: []mode.ParsedGroupEntry{{Name: "read"}}}
+	if got := Modes([]*mode.Config{m}, []*mode.Config{m}); len(got) != 0 {
+		t.Errorf("Modes() = %+v, want no changes", got)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	changes := []ModeChange{
+		{Slug: "a", Kind: Removed},
+		{Slug: "b", Kind: Changed, Fields: []FieldChange{
+			{Field: "name", Old: "B", New: "Bee"},
+			{Field: "description", Old: "", New: "New"},
+			{Field: "roleDefinition", Old: "You are Roo\nOld", New: "You are Roo\nNew", Text: true},
+		}},
+		{Slug: "c", Kind: Added},
+	}
+	want := `- a: only in .roomodes
+~ b
+    name: "B" -> "Bee"
+    description: (none) -> "New"
+    roleDefinition:
+      --- .roomodes
+      +++ .roo/modes
+      @@ -1,2 +1,2 @@
+       You are Roo
+      -Old
+      +New
++ c: only in .roo/modes
+`
+
+	var buf bytes.Buffer
+	if err := WriteText(&buf, changes, ".roomodes", ".roo/modes"); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	changes := []ModeChange{
+		{Slug: "a", Kind: Removed},
+		{Slug: "b", Kind: Changed, Fields: []FieldChange{
+			{Field: "groups.edit.fileRegex", Old: `a|b`, New: ""},
+			{Field: "customInstructions", Old: "```go\nx\n```", New: "y", Text: true},
+		}},
+	}
+	want := "## Custom mode changes: `old` → `new`\n\n" +
+		"- Removed mode `a`\n\n" +
+		"### `b`\n\n" +
+		"| Field | Old | New |\n| --- | --- | --- |\n" +
+		"| groups.edit.fileRegex | `a\\|b` | _(none)_ |\n\n" +
+		"`customInstructions`:\n\n" +
+		"````diff\n--- old\n+++ new\n@@ -1,3 +1 @@\n-```go\n-x\n-```\n+y\n````\n"
+
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, changes, "old", "new"); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("WriteMarkdown() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,137 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteText writes mode changes as plain text
+// oldName and newName label the two sides of the comparison.
+func WriteText(w io.Writer, changes []ModeChange, oldName, newName string) error {
+	var buf strings.Builder
+	for _, c := range changes {
+		switch c.Kind {
+		case Added:
+			fmt.Fprintf(&buf, "+ %s: only in %s\n", c.Slug, newName)
+		case Removed:
+			fmt.Fprintf(&buf, "- %s: only in %s\n", c.Slug, oldName)
+		case Changed:
+			fmt.Fprintf(&buf, "~ %s\n", c.Slug)
+			for _, f := range c.Fields {
+				if !f.Text {
+					fmt.Fprintf(&buf, "    %s: %s -> %s\n", f.Field, quoteValue(f.Old), quoteValue(f.New))
+					continue
+				}
+				fmt.Fprintf(&buf, "    %s:\n", f.Field)
+				for _, line := range splitLines(Unified(oldName, newName, f.Old, f.New)) {
+					fmt.Fprintf(&buf, "      %s\n", line)
+				}
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// quoteValue formats a field value, showing unset values as (none)
+func quoteValue(v string) string {
+	if v == "" {
+		return "(none)"
+	}
+	return strconv.Quote(v)
+}
+
+// WriteMarkdown writes mode changes as GitHub flavored Markdown, for example to post as a
+// pull request comment
+// oldName and newName label the two sides of the comparison.
+func WriteMarkdown(w io.Writer, changes []ModeChange, oldName, newName string) error {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "## Custom mode changes: %s → %s\n\n", codeSpan(oldName), codeSpan(newName))
+
+	for _, c := range changes {
+		switch c.Kind {
+		case Added:
+			fmt.Fprintf(&buf, "- Added mode %s\n", codeSpan(c.Slug))
+		case Removed:
+			fmt.Fprintf(&buf, "- Removed mode %s\n", codeSpan(c.Slug))
+		}
+	}
+
+	for _, c := range changes {
+		if c.Kind != Changed {
+			continue
+		}
+
+		fmt.Fprintf(&buf, "\n### %s\n", codeSpan(c.Slug))
+
+		var textFields []FieldChange
+		table := false
+		for _, f := range c.Fields {
+			if f.Text {
+				textFields = append(textFields, f)
+				continue
+			}
+			if !table {
+				buf.WriteString("\n| Field | Old | New |\n| --- | --- | --- |\n")
+				table = true
+			}
+			fmt.Fprintf(&buf, "| %s | %s | %s |\n", f.Field, markdownCell(f.Old), markdownCell(f.New))
+		}
+
+		for _, f := range textFields {
+			unified := Unified(oldName, newName, f.Old, f.New)
+			fence := codeFence(unified)
+			fmt.Fprintf(&buf, "\n%s:\n\n%sdiff\n%s%s\n", codeSpan(f.Field), fence, unified, fence)
+		}
+	}
+
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// markdownCell formats a field value for a Markdown table cell
+func markdownCell(v string) string {
+	if v == "" {
+		return "_(none)_"
+	}
+	v = strings.ReplaceAll(v, "|", "\\|")
+	v = strings.ReplaceAll(v, "\n", " ")
+	return codeSpan(v)
+}
+
+// codeSpan formats s as Markdown inline code
+func codeSpan(s string) string {
+	ticks := strings.Repeat("`", longestBacktickRun(s)+1)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		return ticks + " " + s + " " + ticks
+	}
+	return ticks + s + ticks
+}
+
+// codeFence returns a fence long enough to hold s in a Markdown code block
+func codeFence(s string) string {
+	n := longestBacktickRun(s) + 1
+	if n < 3 {
+		n = 3
+	}
+	return strings.Repeat("`", n)
+}
+
+// longestBacktickRun returns the length of the longest run of backticks in s
+func longestBacktickRun(s string) int {
+	longest, run := 0, 0
+	for _, r := range s {
+		if r != '`' {
+			run = 0
+			continue
+		}
+		run++
+		if run > longest {
+			longest = run
+		}
+	}
+	return longest
+}
@@ -53,10 +53,10 @@ func CreateTemplateFile(filePath string, template string) error {
 func GetModeTemplate(name string) string {
 	return fmt.Sprintf(`---
 name: %s
+roleDefinition: You are Roo, ... (describe the role of the mode here)
 groups:
   - read
   - edit
-customInstructions: null
 ---
 
 # %s
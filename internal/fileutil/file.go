@@ -4,20 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 )
 
-var (
-	// Regular expression for valid filenames
-	validFilenameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
-)
-
-// IsValidFilename checks if a filename is valid
-func IsValidFilename(filename string) bool {
-	return validFilenameRegex.MatchString(filename)
-}
-
 // GetModesDir returns the directory for storing mode files
 // Uses .roo/modes by default
 func GetModesDir() (string, error) {
@@ -41,6 +30,12 @@ func GetModeFilePath(slug string) (string, error) {
 	return filepath.Join(modesDir, slug+".md"), nil
 }
 
+// GetRulesDir returns the directory for the rule files of a mode
+// RooCode loads mode-specific rules from .roo/rules-{slug}
+func GetRulesDir(slug string) string {
+	return filepath.Join(".roo", "rules-"+slug)
+}
+
 // ListModeFiles returns all Markdown files in the modes directory
 func ListModeFiles() ([]string, error) {
 	modesDir, err := GetModesDir()
@@ -48,7 +43,12 @@ func ListModeFiles() ([]string, error) {
 		return nil, err
 	}
 
-	entries, err := os.ReadDir(modesDir)
+	return ListMarkdownFiles(modesDir)
+}
+
+// ListMarkdownFiles returns all Markdown files directly inside dir
+func ListMarkdownFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read modes directory: %w", err)
 	}
@@ -57,13 +57,37 @@ func ListModeFiles() ([]string, error) {
 	var modeFiles []string
 	for _, entry := range entries {
 		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".md") {
-			modeFiles = append(modeFiles, filepath.Join(modesDir, entry.Name()))
+			modeFiles = append(modeFiles, filepath.Join(dir, entry.Name()))
 		}
 	}
 
 	return modeFiles, nil
 }
 
+// ListFiles returns all files below dir, including subdirectories, in lexical order
+// Returns an empty list if dir doesn't exist
+func ListFiles(dir string) ([]string, error) {
+	if !FileExists(dir) {
+		return nil, nil
+	}
+
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	return files, nil
+}
+
 // FileExists checks if a file exists
 func FileExists(path string) bool {
 	_, err := os.Stat(path)
@@ -0,0 +1,82 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/upamune/roomode/internal/mode"
+)
+
+// DefaultConfigFile is the lint configuration file looked up in the working directory
+const DefaultConfigFile = ".roomode-lint.yaml"
+
+// SeverityOff disables a rule
+const SeverityOff = "off"
+
+// Config represents the lint settings loaded from .roomode-lint.yaml
+//
+// Example:
+//
+//	rules:
+//	  role-definition-prefix: error
+//	  when-to-use-required: off
+type Config struct {
+	Rules map[string]string `yaml:"rules"` // Rule ID to severity (error, warning or off)
+}
+
+// DefaultConfig returns a configuration that uses each rule's default severity
+func DefaultConfig() *Config {
+	return &Config{Rules: map[string]string{}}
+}
+
+// LoadConfig loads the lint configuration from path
+// If path is empty, DefaultConfigFile is used when it exists
+func LoadConfig(path string) (*Config, error) {
+	if path == "" {
+		if _, err := os.Stat(DefaultConfigFile); os.IsNotExist(err) {
+			return DefaultConfig(), nil
+		}
+		path = DefaultConfigFile
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lint config: %w", err)
+	}
+
+	config := DefaultConfig()
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse lint config: %w", err)
+	}
+	if config.Rules == nil {
+		config.Rules = map[string]string{}
+	}
+
+	// Check rule IDs and severities
+	for id, severity := range config.Rules {
+		if FindRule(id) == nil {
+			return nil, fmt.Errorf("unknown lint rule in %s: %s", path, id)
+		}
+		switch severity {
+		case string(mode.SeverityError), string(mode.SeverityWarning), SeverityOff:
+		default:
+			return nil, fmt.Errorf("invalid severity for lint rule %s: %q (must be error, warning or off)", id, severity)
+		}
+	}
+
+	return config, nil
+}
+
+// severity returns the configured severity of a rule, or "" if the rule is disabled
+func (c *Config) severity(rule *Rule) mode.Severity {
+	configured, ok := c.Rules[rule.ID]
+	if !ok {
+		return rule.DefaultSeverity
+	}
+	if configured == SeverityOff {
+		return ""
+	}
+	return mode.Severity(configured)
+}
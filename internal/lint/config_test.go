@@ -0,0 +1,113 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "rule severities",
+			content: `rules:
+  role-definition-prefix: warning
+  when-to-use-required: off
+  no-empty-sections: error
+`,
+			want: map[string]string{
+				"role-definition-prefix": "warning",
+				"when-to-use-required":   "off",
+				"no-empty-sections":      "error",
+			},
+		},
+		{
+			name:    "empty file",
+			content: "",
+			want:    map[string]string{},
+		},
+		{
+			name:    "no rules",
+			content: "rules:\n",
+			want:    map[string]string{},
+		},
+		{
+			name:    "unknown rule",
+			content: "rules:\n  no-such-rule: error\n",
+			wantErr: "unknown lint rule",
+		},
+		{
+			name:    "invalid severity",
+			content: "rules:\n  no-empty-sections: fatal\n",
+			wantErr: `invalid severity for lint rule no-empty-sections: "fatal"`,
+		},
+		{
+			name:    "invalid YAML",
+			content: "rules: [",
+			wantErr: "failed to parse lint config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DefaultConfigFile)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := LoadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig() error = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			if !reflect.DeepEqual(config.Rules, tt.want) {
+				t.Errorf("Rules = %v, want %v", config.Rules, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigDefaultFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	// Without a config file every rule uses its default severity
+	config, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(config.Rules) != 0 {
+		t.Errorf("Rules = %v, want none", config.Rules)
+	}
+
+	if err := os.WriteFile(DefaultConfigFile, []byte("rules:\n  when-to-use-required: off\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err = LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if got := config.severity(FindRule("when-to-use-required")); got != "" {
+		t.Errorf("severity(when-to-use-required) = %q, want disabled", got)
+	}
+	if got := config.severity(FindRule("no-empty-sections")); got != "error" {
+		t.Errorf("severity(no-empty-sections) = %q, want the default error", got)
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadConfig() error = nil, want an error for a missing file")
+	}
+}
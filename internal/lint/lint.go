@@ -0,0 +1,106 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/upamune/roomode/internal/mode"
+)
+
+// Target is a mode file being linted
+type Target struct {
+	Config *mode.Config
+	Lines  []string // Raw lines of the mode file
+}
+
+// Rule is a single style check for mode files
+type Rule struct {
+	ID              string
+	Description     string
+	DefaultSeverity mode.Severity
+	Check           func(t *Target) mode.Diagnostics
+}
+
+// FindRule returns the rule with the given ID, or nil
+func FindRule(id string) *Rule {
+	for i := range Rules {
+		if Rules[i].ID == id {
+			return &Rules[i]
+		}
+	}
+	return nil
+}
+
+// suppressionComment matches per-file suppression comments such as
+// "<!-- roomode-lint-disable rule-a rule-b -->" or "# roomode-lint-disable rule-a"
+var suppressionComment = regexp.MustCompile(`(?:<!--|#)\s*roomode-lint-disable\b(.*?)\s*(?:-->)?\s*$`)
+
+// LintFile runs all enabled rules against a mode file
+// A file that can't be parsed is reported as a single error
+func LintFile(config *Config, filePath string) mode.Diagnostics {
+	modeConfig, err := mode.LoadModeFile(filePath)
+	if err != nil {
+		return mode.AsDiagnostics(filePath, err)
+	}
+
+	data, err := os.ReadFile(modeConfig.FilePath)
+	if err != nil {
+		return mode.Diagnostics{{File: filePath, Message: fmt.Sprintf("failed to read file: %s", err)}}
+	}
+
+	target := &Target{
+		Config: modeConfig,
+		Lines:  strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"),
+	}
+	disabled, disableAll := suppressedRules(target.Lines)
+	if disableAll {
+		return nil
+	}
+
+	var diags mode.Diagnostics
+	for i := range Rules {
+		rule := &Rules[i]
+		severity := config.severity(rule)
+		if severity == "" || disabled[rule.ID] {
+			continue
+		}
+
+		for _, d := range rule.Check(target) {
+			d.File = modeConfig.FilePath
+			d.Severity = severity
+			d.Rule = rule.ID
+			if d.Line == 0 {
+				pos := modeConfig.Position(d.Field)
+				d.Line, d.Column = pos.Line, pos.Column
+			}
+			diags = append(diags, d)
+		}
+	}
+
+	return diags
+}
+
+// suppressedRules collects rules disabled by suppression comments
+// A comment without rule IDs disables all rules for the file
+func suppressedRules(lines []string) (map[string]bool, bool) {
+	disabled := map[string]bool{}
+	for _, line := range lines {
+		m := suppressionComment.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		ids := strings.FieldsFunc(m[1], func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		if len(ids) == 0 {
+			return nil, true
+		}
+		for _, id := range ids {
+			disabled[id] = true
+		}
+	}
+	return disabled, false
+}
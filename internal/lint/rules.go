@@ -0,0 +1,152 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/upamune/roomode/internal/mode"
+)
+
+// Rules lists all built-in lint rules
+var Rules = []Rule{
+	{
+		ID:              "role-definition-prefix",
+		Description:     `roleDefinition must start with "You are Roo"`,
+		DefaultSeverity: mode.SeverityError,
+		Check:           checkRoleDefinitionPrefix,
+	},
+	{
+		ID:              "when-to-use-required",
+		Description:     "whenToUse must be present so the Orchestrator can pick the mode",
+		DefaultSeverity: mode.SeverityWarning,
+		Check:           checkWhenToUseRequired,
+	},
+	{
+		ID:              "no-empty-sections",
+		Description:     "Markdown sections in the body must not be empty",
+		DefaultSeverity: mode.SeverityError,
+		Check:           checkNoEmptySections,
+	},
+	{
+		ID:              "edit-without-file-regex",
+		Description:     "The edit group should be restricted with a fileRegex",
+		DefaultSeverity: mode.SeverityWarning,
+		Check:           checkEditWithoutFileRegex,
+	},
+}
+
+// rolePrefix is the prefix RooCode's built-in modes use for role definitions
+const rolePrefix = "You are Roo"
+
+func checkRoleDefinitionPrefix(t *Target) mode.Diagnostics {
+	if strings.HasPrefix(strings.TrimSpace(t.Config.RoleDefinition), rolePrefix) {
+		return nil
+	}
+	return mode.Diagnostics{{
+		Field:   "roleDefinition",
+		Message: fmt.Sprintf("roleDefinition should start with %q", rolePrefix),
+	}}
+}
+
+func checkWhenToUseRequired(t *Target) mode.Diagnostics {
+	if strings.TrimSpace(t.Config.WhenToUse) != "" {
+		return nil
+	}
+	return mode.Diagnostics{{
+		Field:   "whenToUse",
+		Message: "whenToUse is missing",
+	}}
+}
+
+func checkEditWithoutFileRegex(t *Target) mode.Diagnostics {
+	var diags mode.Diagnostics
+	for i, group := range t.Config.GroupsParsed {
+		if group.Name != mode.GroupEdit {
+			continue
+		}
+		if group.Options == nil || group.Options.FileRegex == nil {
+			diags = append(diags, mode.Diagnostic{
+				Field:   fmt.Sprintf("groups[%d]", i),
+				Message: "edit group has no fileRegex, so the mode can edit any file",
+			})
+		}
+	}
+	return diags
+}
+
+// checkNoEmptySections reports headings that are directly followed by a heading
+// of the same or a higher level, or by the end of the file
+func checkNoEmptySections(t *Target) mode.Diagnostics {
+	type heading struct {
+		level int
+		line  int // 1-based line in the file
+		title string
+	}
+
+	var diags mode.Diagnostics
+	var open *heading
+	inFence := false
+
+	closeSection := func() {
+		if open != nil {
+			diags = append(diags, mode.Diagnostic{
+				Line:    open.line,
+				Column:  1,
+				Message: fmt.Sprintf("section %q is empty", open.title),
+			})
+		}
+	}
+
+	start := t.Config.BodyLine - 1
+	if start < 0 {
+		start = 0
+	}
+
+	for i := start; i < len(t.Lines); i++ {
+		line := strings.TrimSpace(t.Lines[i])
+
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+			inFence = !inFence
+			open = nil
+			continue
+		}
+		if inFence || line == "" || isSuppressionComment(line) {
+			continue
+		}
+
+		level := headingLevel(line)
+		if level == 0 {
+			// Any content makes the open section non-empty
+			open = nil
+			continue
+		}
+
+		if open != nil && level <= open.level {
+			closeSection()
+		}
+		open = &heading{level: level, line: i + 1, title: strings.TrimSpace(line[level:])}
+	}
+	closeSection()
+
+	return diags
+}
+
+// headingLevel returns the level of an ATX heading line, or 0 if line isn't a heading
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0
+	}
+	if level < len(line) && line[level] != ' ' && line[level] != '\t' {
+		return 0
+	}
+	return level
+}
+
+// isSuppressionComment reports whether line is a lint suppression comment
+func isSuppressionComment(line string) bool {
+	return suppressionComment.MatchString(line)
+}
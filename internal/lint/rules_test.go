@@ -0,0 +1,238 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeModeFile writes a mode file to a temporary directory and returns its path
+func writeModeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.md")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// lintResults lints content and formats each diagnostic as "line:rule:severity"
+func lintResults(t *testing.T, config *Config, content string) []string {
+	t.Helper()
+	var results []string
+	for _, d := range LintFile(config, writeModeFile(t, content)) {
+		results = append(results, fmt.Sprintf("%d:%s:%s", d.Line, d.Rule, d.Severity))
+	}
+	return results
+}
+
+func TestLintFileRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name: "clean mode",
+			content: `---
+name: Test
+roleDefinition: You are Roo, a tester
+whenToUse: Use for tests
+groups:
+  - read
+  - edit:
+      fileRegex: \.test\.ts$
+---
+# Rules
+
+Write tests first.
+`,
+		},
+		{
+			name: "role definition without prefix",
+			content: `---
+name: Test
+roleDefinition: A tester
+whenToUse: Use for tests
+groups: [read]
+---
+`,
+			want: []string{"3:role-definition-prefix:error"},
+		},
+		{
+			name: "role definition prefix after whitespace",
+			content: `---
+name: Test
+roleDefinition: "  You are Roo"
+whenToUse: Use for tests
+groups: [read]
+---
+`,
+		},
+		{
+			name: "missing whenToUse",
+			content: `---
+name: Test
+roleDefinition: You are Roo
+groups: [read]
+---
+`,
+			want: []string{"1:when-to-use-required:warning"},
+		},
+		{
+			name: "blank whenToUse",
+			content: `---
+name: Test
+roleDefinition: You are Roo
+whenToUse: " "
+groups: [read]
+---
+`,
+			want: []string{"4:when-to-use-required:warning"},
+		},
+		{
+			name: "edit group without fileRegex",
+			content: `---
+name: Test
+roleDefinition: You are Roo
+whenToUse: Use for tests
+groups:
+  - read
+  - edit
+---
+`,
+			want: []string{"7:edit-without-file-regex:warning"},
+		},
+		{
+			name: "empty sections",
+			content: `---
+name: Test
+roleDefinition: You are Roo
+whenToUse: Use for tests
+groups: [read]
+---
+# Empty
+
+# Parent
+## Child
+
+Content
+
+## Empty at end
+`,
+			want: []string{"7:no-empty-sections:error", "14:no-empty-sections:error"},
+		},
+		{
+			name: "headings in code fences are ignored",
+			content: `---
+name: Test
+roleDefinition: You are Roo
+whenToUse: Use for tests
+groups: [read]
+---
+# Example
+
+` + "```" + `
+# Not a heading
+` + "```" + `
+`,
+		},
+		{
+			name: "hashes without a space are not headings",
+			content: `---
+name: Test
+roleDefinition: You are Roo
+whenToUse: Use for tests
+groups: [read]
+---
+# Tags
+
+#hashtag
+`,
+		},
+		{
+			name: "rules disabled by comment",
+			content: `---
+name: Test
+roleDefinition: A tester
+groups: [read]
+---
+<!-- roomode-lint-disable role-definition-prefix, when-to-use-required -->
+# Empty
+`,
+			want: []string{"7:no-empty-sections:error"},
+		},
+		{
+			name: "all rules disabled by comment",
+			content: `---
+name: Test
+roleDefinition: A tester
+groups: [read]
+---
+<!-- roomode-lint-disable -->
+# Empty
+`,
+		},
+		{
+			name: "unparsable file",
+			content: `---
+name: [
+---
+`,
+			want: []string{"2::"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lintResults(t, DefaultConfig(), tt.content)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LintFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintFileConfiguredSeverity(t *testing.T) {
+	content := `---
+name: Test
+roleDefinition: A tester
+groups: [edit]
+---
+`
+	config := &Config{Rules: map[string]string{
+		"role-definition-prefix":  "warning",
+		"when-to-use-required":    "error",
+		"edit-without-file-regex": SeverityOff,
+	}}
+
+	got := lintResults(t, config, content)
+	want := []string{"3:role-definition-prefix:warning", "1:when-to-use-required:error"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LintFile() = %q, want %q", got, want)
+	}
+}
+
+func TestHeadingLevel(t *testing.T) {
+	tests := []struct {
+		line string
+		want int
+	}{
+		{"# Title", 1},
+		{"### Title", 3},
+		{"###### Title", 6},
+		{"####### Title", 0},
+		{"#", 1},
+		{"#\tTitle", 1},
+		{"#Title", 0},
+		{"Title", 0},
+	}
+
+	for _, tt := range tests {
+		if got := headingLevel(tt.line); got != tt.want {
+			t.Errorf("headingLevel(%q) = %d, want %d", tt.line, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package mode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Severity is the severity of a diagnostic
+type Severity string
+
+// Diagnostic severities
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic describes a single problem found in a mode file
+type Diagnostic struct {
+	File     string // Path to the mode file
+	Line     int    // 1-based line number (0 if unknown)
+	Column   int    // 1-based column number (0 if unknown)
+	Field    string // Frontmatter field path, e.g. "groups[3].fileRegex"
+	Message  string
+	Severity Severity // Empty for validation errors
+	Rule     string   // ID of the lint rule that reported the problem, if any
+}
+
+// String formats the diagnostic as file:line:col: message
+// Severity and rule ID are included when set
+func (d Diagnostic) String() string {
+	msg := d.Message
+	if d.Severity != "" {
+		msg = fmt.Sprintf("%s: %s", d.Severity, msg)
+	}
+	if d.Rule != "" {
+		msg = fmt.Sprintf("%s (%s)", msg, d.Rule)
+	}
+
+	switch {
+	case d.File == "":
+		return msg
+	case d.Line == 0:
+		return fmt.Sprintf("%s: %s", d.File, msg)
+	default:
+		return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Column, msg)
+	}
+}
+
+// IsError reports whether the diagnostic is an error
+// Diagnostics without a severity are validation errors
+func (d Diagnostic) IsError() bool {
+	return d.Severity == "" || d.Severity == SeverityError
+}
+
+// Diagnostics is a list of problems that can be returned as a single error
+type Diagnostics []Diagnostic
+
+// Error joins all diagnostic messages, prefixed with their line and column when known
+func (ds Diagnostics) Error() string {
+	messages := make([]string, 0, len(ds))
+	for _, d := range ds {
+		if d.Line > 0 {
+			messages = append(messages, fmt.Sprintf("%d:%d: %s", d.Line, d.Column, d.Message))
+		} else {
+			messages = append(messages, d.Message)
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// AsDiagnostics converts an error into diagnostics
+// Errors that don't carry diagnostics are reported as a single diagnostic for file
+func AsDiagnostics(file string, err error) Diagnostics {
+	var diags Diagnostics
+	if errors.As(err, &diags) {
+		return diags
+	}
+	return Diagnostics{{File: file, Message: err.Error()}}
+}
+
+// errorOrNil returns the diagnostics as an error, or nil if there are none
+func (ds Diagnostics) errorOrNil() error {
+	if len(ds) == 0 {
+		return nil
+	}
+	return ds
+}
+
+// locate returns a copy of the diagnostics with File set to path and missing
+// positions filled in from the recorded field positions
+func (ds Diagnostics) locate(path string, positions map[string]Position) Diagnostics {
+	result := make(Diagnostics, len(ds))
+	for i, d := range ds {
+		d.File = path
+		if d.Line == 0 {
+			pos := lookupPosition(positions, d.Field)
+			d.Line, d.Column = pos.Line, pos.Column
+		}
+		result[i] = d
+	}
+	return result
+}
@@ -0,0 +1,160 @@
+package mode
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadModeFile parses a mode file and resolves the modes it extends
+// A mode that was only partially parsed is returned together with its diagnostics
+func LoadModeFile(filePath string) (*Config, error) {
+	config, err := ParseModeFile(filePath)
+	if err != nil {
+		if config == nil {
+			return nil, err
+		}
+		resolved, resolveErr := ResolveExtends(config)
+		if resolveErr != nil {
+			return config, append(AsDiagnostics(filePath, err), AsDiagnostics(filePath, resolveErr)...)
+		}
+		return resolved, err
+	}
+	return ResolveExtends(config)
+}
+
+// ResolveExtends returns the mode with the mode it extends merged in
+// The extended mode is loaded by slug from the directory of the mode file, and may itself
+// extend another mode. Cycles are reported as an error on the extends field.
+//
+// Modes are merged as follows:
+//   - groups are merged by name: the parent's groups come first, options set by the child
+//     override the parent's options one by one, and groups only listed by the child are appended
+//   - roleDefinition is inherited unless the child sets it
+//   - custom instructions of the child are appended to the parent's
+//   - vars are merged, with the child's values taking precedence, and the mode is a template
+//     if either mode is one
+//   - name, whenToUse, description and source are never inherited
+func ResolveExtends(config *Config) (*Config, error) {
+	return resolveExtends(config, []string{config.Slug})
+}
+
+// resolveExtends resolves config, where chain lists the slugs of the modes being resolved
+func resolveExtends(config *Config, chain []string) (*Config, error) {
+	if config.Extends == "" {
+		return config, nil
+	}
+
+	fail := func(format string, args ...interface{}) error {
+		diags := Diagnostics{{Field: "extends", Message: fmt.Sprintf(format, args...)}}
+		return diags.locate(config.FilePath, config.Positions)
+	}
+
+	for _, slug := range chain {
+		if slug == config.Extends {
+			return nil, fail("extends cycle: %s", strings.Join(append(chain, config.Extends), " -> "))
+		}
+	}
+
+	parentPath := filepath.Join(filepath.Dir(config.FilePath), config.Extends+".md")
+	if _, err := os.Stat(parentPath); err != nil {
+		return nil, fail("extended mode %q not found", config.Extends)
+	}
+
+	parent, err := ParseModeFile(parentPath)
+	if err != nil {
+		return nil, fail("failed to load extended mode %q: %s", config.Extends, err)
+	}
+
+	parentChain := make([]string, len(chain), len(chain)+1)
+	copy(parentChain, chain)
+	parent, err = resolveExtends(parent, append(parentChain, parent.Slug))
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeModes(parent, config), nil
+}
+
+// mergeModes merges a child mode into its resolved parent
+func mergeModes(parent, child *Config) *Config {
+	merged := *child
+	merged.GroupsParsed = mergeGroups(parent.GroupsParsed, child.GroupsParsed)
+	merged.groupIndexes = nil
+
+	if merged.RoleDefinition == "" {
+		merged.RoleDefinition = parent.RoleDefinition
+	}
+
+	switch {
+	case parent.CustomInstructions == nil:
+	case child.CustomInstructions == nil:
+		merged.CustomInstructions = parent.CustomInstructions
+	default:
+		instructions := *parent.CustomInstructions + "\n\n" + *child.CustomInstructions
+		merged.CustomInstructions = &instructions
+	}
+
+	merged.Template = parent.Template || child.Template
+	if len(parent.Vars) > 0 {
+		merged.Vars = make(map[string]interface{}, len(parent.Vars)+len(child.Vars))
+		for k, v := range parent.Vars {
+			merged.Vars[k] = v
+		}
+		for k, v := range child.Vars {
+			merged.Vars[k] = v
+		}
+	}
+
+	// Group indices in the child file don't match the merged groups
+	merged.Positions = make(map[string]Position, len(child.Positions))
+	for field, pos := range child.Positions {
+		if !strings.HasPrefix(field, "groups[") {
+			merged.Positions[field] = pos
+		}
+	}
+
+	return &merged
+}
+
+// mergeGroups merges child groups into parent groups by name
+func mergeGroups(parent, child []ParsedGroupEntry) []ParsedGroupEntry {
+	merged := make([]ParsedGroupEntry, len(parent), len(parent)+len(child))
+	copy(merged, parent)
+
+	index := make(map[string]int, len(merged))
+	for i, group := range merged {
+		index[group.Name] = i
+	}
+
+	for _, group := range child {
+		if i, ok := index[group.Name]; ok {
+			merged[i].Options = mergeGroupOptions(merged[i].Options, group.Options)
+			continue
+		}
+		index[group.Name] = len(merged)
+		merged = append(merged, group)
+	}
+
+	return merged
+}
+
+// mergeGroupOptions returns base with the options set in override replaced
+func mergeGroupOptions(base, override *GroupOptions) *GroupOptions {
+	if override == nil {
+		return base
+	}
+	if base == nil {
+		return override
+	}
+
+	merged := *base
+	if override.FileRegex != nil {
+		merged.FileRegex = override.FileRegex
+	}
+	if override.Description != nil {
+		merged.Description = override.Description
+	}
+	return &merged
+}
@@ -0,0 +1,346 @@
+package mode
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Frontmatter formats supported in mode files
+const (
+	FrontmatterYAML = "yaml"
+	FrontmatterTOML = "toml"
+	FrontmatterJSON = "json"
+)
+
+// FrontmatterFormats lists the supported frontmatter formats
+var FrontmatterFormats = []string{FrontmatterYAML, FrontmatterTOML, FrontmatterJSON}
+
+// frontmatterDelimiters lists the opening and closing delimiter lines of each format,
+// the same ones Hugo and adrg/frontmatter understand
+// A JSON object starting with a "{" line is frontmatter including its delimiters, and ends at
+// the first "}" line that isn't indented, so closing braces of nested objects don't end it
+var frontmatterDelimiters = []struct {
+	start, end    string
+	format        string
+	includeDelims bool
+}{
+	{start: "---", end: "---", format: FrontmatterYAML},
+	{start: "---yaml", end: "---", format: FrontmatterYAML},
+	{start: "+++", end: "+++", format: FrontmatterTOML},
+	{start: "---toml", end: "---", format: FrontmatterTOML},
+	{start: ";;;", end: ";;;", format: FrontmatterJSON},
+	{start: "---json", end: "---", format: FrontmatterJSON},
+	{start: "{", end: "}", format: FrontmatterJSON, includeDelims: true},
+}
+
+// frontmatterBlock is the raw frontmatter block of a mode file
+type frontmatterBlock struct {
+	format   string // One of FrontmatterFormats
+	data     []byte // Frontmatter content
+	dataLine int    // Line number of the first line of data
+	line     int    // Line number of the opening delimiter
+	start    int    // Offset of the content after the opening delimiter in the file
+	end      int    // Offset of the closing delimiter in the file
+	body     []byte // Content after the closing delimiter
+	bodyLine int    // Line number of the first body line
+}
+
+// splitFrontmatter extracts the frontmatter block at the start of a mode file
+// Leading empty lines are skipped
+// Returns false if the file doesn't start with a complete frontmatter block
+func splitFrontmatter(data []byte) (*frontmatterBlock, bool) {
+	offset := 0
+	line := 0
+	dataStart := -1
+	end := ""
+	includeDelims := false
+	fm := &frontmatterBlock{}
+
+	for offset < len(data) {
+		i := bytes.IndexByte(data[offset:], '\n')
+		next := len(data)
+		if i >= 0 {
+			next = offset + i + 1
+		}
+		line++
+		text := string(bytes.TrimSpace(data[offset:next]))
+
+		if dataStart < 0 {
+			if text == "" {
+				offset = next
+				continue
+			}
+			for _, d := range frontmatterDelimiters {
+				if text == d.start {
+					fm.format, end, includeDelims = d.format, d.end, d.includeDelims
+					break
+				}
+			}
+			if fm.format == "" {
+				return nil, false
+			}
+
+			fm.line = line
+			fm.start = next
+			dataStart, fm.dataLine = next, line+1
+			if includeDelims {
+				dataStart, fm.dataLine = offset, line
+			}
+		} else if text == end && (!includeDelims || data[offset] == end[0]) {
+			fm.data = data[dataStart:offset]
+			fm.end = offset
+			if includeDelims {
+				fm.data = data[dataStart:next]
+			}
+			fm.body = data[next:]
+			fm.bodyLine = line + 1
+			return fm, true
+		}
+
+		offset = next
+	}
+
+	return nil, false
+}
+
+// DetectFrontmatterFormat returns the frontmatter format of a mode file,
+// or an empty string if it doesn't start with a frontmatter block
+func DetectFrontmatterFormat(data []byte) string {
+	fm, ok := splitFrontmatter(data)
+	if !ok {
+		return ""
+	}
+	return fm.format
+}
+
+// SplitYAMLFrontmatter splits a mode file into the part up to and including the
+// opening "---" delimiter, the YAML frontmatter, and the rest of the file starting
+// with the closing delimiter
+// Returns false if the file doesn't start with a YAML frontmatter block
+func SplitYAMLFrontmatter(data []byte) (head, yamlData, tail []byte, ok bool) {
+	fm, ok := splitFrontmatter(data)
+	if !ok || fm.format != FrontmatterYAML {
+		return nil, nil, nil, false
+	}
+	return data[:fm.start], fm.data, data[fm.end:], true
+}
+
+// decodeFrontmatter decodes frontmatter in any format into metadata, and returns the
+// position of each frontmatter field, the top-level keys present in the frontmatter and
+// the unknown keys found
+// TOML and JSON are converted to a YAML node tree with the positions of their source, so
+// all formats share the same decoding, positions and unknown key checks
+// Unknown keys don't stop decoding, so they are returned apart from the error
+func decodeFrontmatter(fm *frontmatterBlock, metadata *Metadata) (map[string]Position, map[string]bool, Diagnostics, error) {
+	if fm.format == FrontmatterYAML {
+		return decodeYAMLFrontmatter(fm, metadata)
+	}
+
+	positions := map[string]Position{
+		"": {Line: fm.line, Column: 1},
+	}
+	lineOffset := fm.dataLine - 1
+
+	var raw map[string]interface{}
+	var sourcePositions map[string]sourcePosition
+	switch fm.format {
+	case FrontmatterTOML:
+		if _, err := toml.Decode(string(fm.data), &raw); err != nil {
+			return positions, nil, nil, tomlDiagnostics(err, lineOffset)
+		}
+		sourcePositions = tomlPositions(fm.data)
+	case FrontmatterJSON:
+		if err := json.Unmarshal(fm.data, &raw); err != nil {
+			return positions, nil, nil, jsonDiagnostics(err, fm.data, lineOffset)
+		}
+		sourcePositions = jsonPositions(fm.data)
+	}
+
+	// Nodes get the positions of their source, relative to the frontmatter like YAML nodes
+	var root yaml.Node
+	if err := root.Encode(raw); err != nil {
+		return positions, nil, nil, Diagnostics{{Line: fm.line, Column: 1, Message: err.Error()}}
+	}
+	setNodePositions(&root, "", sourcePositions, Position{Line: fm.line - lineOffset, Column: 1})
+
+	keys := topLevelKeys(&root)
+	if err := root.Decode(metadata); err != nil {
+		return positions, keys, nil, yamlDiagnostics(err, lineOffset)
+	}
+
+	recordPositions(&root, lineOffset, positions)
+
+	// Unknown keys are errors unless the file opts out with "strict: false"
+	var unknown Diagnostics
+	if metadata.Strict == nil || *metadata.Strict {
+		unknown = unknownKeys(&root, lineOffset)
+	}
+
+	return positions, keys, unknown, nil
+}
+
+// decodeYAMLFrontmatter decodes YAML frontmatter into metadata, and returns the
+// position of each frontmatter field, the top-level keys present in the frontmatter and
+// the unknown keys found
+func decodeYAMLFrontmatter(fm *frontmatterBlock, metadata *Metadata) (map[string]Position, map[string]bool, Diagnostics, error) {
+	positions := map[string]Position{
+		"": {Line: fm.line, Column: 1},
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(fm.data, &root); err != nil {
+		return positions, nil, nil, yamlDiagnostics(err, fm.line)
+	}
+
+	// Empty frontmatter
+	if root.Kind == 0 {
+		return positions, map[string]bool{}, nil, nil
+	}
+
+	keys := topLevelKeys(&root)
+	if err := root.Decode(metadata); err != nil {
+		return positions, keys, nil, yamlDiagnostics(err, fm.line)
+	}
+
+	recordPositions(&root, fm.line, positions)
+
+	// Unknown keys are errors unless the file opts out with "strict: false"
+	var unknown Diagnostics
+	if metadata.Strict == nil || *metadata.Strict {
+		unknown = unknownKeys(&root, fm.line)
+	}
+
+	return positions, keys, unknown, nil
+}
+
+// topLevelKeys returns the keys of the top-level mapping of a frontmatter node tree
+func topLevelKeys(root *yaml.Node) map[string]bool {
+	doc := root
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		doc = doc.Content[0]
+	}
+
+	keys := map[string]bool{}
+	if doc.Kind != yaml.MappingNode {
+		return keys
+	}
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		keys[doc.Content[i].Value] = true
+	}
+	return keys
+}
+
+// recordPositions walks the YAML node tree and stores field positions
+// lineOffset is added to node lines to convert them to file lines
+func recordPositions(root *yaml.Node, lineOffset int, positions map[string]Position) {
+	pos := func(n *yaml.Node) Position {
+		return Position{Line: n.Line + lineOffset, Column: n.Column}
+	}
+
+	doc := root
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		doc = doc.Content[0]
+	}
+	if doc.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key, value := doc.Content[i], doc.Content[i+1]
+		positions[key.Value] = pos(value)
+
+		if key.Value != "groups" || value.Kind != yaml.SequenceNode {
+			continue
+		}
+
+		for j, entry := range value.Content {
+			field := fmt.Sprintf("groups[%d]", j)
+			positions[field] = pos(entry)
+
+			// Both [name, options] and {name: options} keep options as the second child
+			if (entry.Kind != yaml.SequenceNode && entry.Kind != yaml.MappingNode) || len(entry.Content) != 2 {
+				continue
+			}
+			options := entry.Content[1]
+			if options.Kind != yaml.MappingNode {
+				continue
+			}
+			for k := 0; k+1 < len(options.Content); k += 2 {
+				positions[field+"."+options.Content[k].Value] = pos(options.Content[k+1])
+			}
+		}
+	}
+}
+
+// yamlErrorLine matches the line prefix of yaml.v3 error messages
+var yamlErrorLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// tomlDiagnostics converts a TOML decoding error into diagnostics with file positions
+// lineOffset is added to the line numbers reported by the TOML decoder
+func tomlDiagnostics(err error, lineOffset int) Diagnostics {
+	d := Diagnostic{Message: err.Error(), Line: lineOffset + 1, Column: 1}
+
+	var parseErr toml.ParseError
+	if errors.As(err, &parseErr) {
+		d.Line = parseErr.Position.Line + lineOffset
+		d.Column = parseErr.Position.Col
+		d.Message = parseErr.Message
+	}
+
+	return Diagnostics{d}
+}
+
+// jsonDiagnostics converts a JSON decoding error into diagnostics with file positions
+// lineOffset is added to the line numbers computed from the error offset in data
+func jsonDiagnostics(err error, data []byte, lineOffset int) Diagnostics {
+	offset := int64(-1)
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	}
+
+	d := Diagnostic{Message: err.Error(), Line: lineOffset + 1, Column: 1}
+	if offset >= 0 && offset <= int64(len(data)) {
+		before := data[:offset]
+		d.Line = bytes.Count(before, []byte("\n")) + 1 + lineOffset
+		d.Column = len(before) - bytes.LastIndexByte(before, '\n')
+	}
+
+	return Diagnostics{d}
+}
+
+// yamlDiagnostics converts a yaml.v3 error into diagnostics with file positions
+// lineOffset is added to the line numbers reported by yaml.v3
+func yamlDiagnostics(err error, lineOffset int) Diagnostics {
+	messages := []string{err.Error()}
+
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	}
+
+	diags := make(Diagnostics, 0, len(messages))
+	for _, msg := range messages {
+		d := Diagnostic{Message: msg, Line: lineOffset, Column: 1}
+		if m := yamlErrorLine.FindStringSubmatch(msg); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			d.Line = line + lineOffset
+			d.Message = m[2]
+		}
+		diags = append(diags, d)
+	}
+
+	return diags
+}
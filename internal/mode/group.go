@@ -0,0 +1,23 @@
+package mode
+
+// Tool groups that RooCode accepts in custom modes
+const (
+	GroupRead    = "read"
+	GroupEdit    = "edit"
+	GroupBrowser = "browser"
+	GroupCommand = "command"
+	GroupMCP     = "mcp"
+)
+
+// ToolGroups lists all tool groups known to RooCode, in RooCode's order
+var ToolGroups = []string{GroupRead, GroupEdit, GroupBrowser, GroupCommand, GroupMCP}
+
+// IsToolGroup reports whether name is a tool group known to RooCode
+func IsToolGroup(name string) bool {
+	for _, group := range ToolGroups {
+		if group == name {
+			return true
+		}
+	}
+	return false
+}
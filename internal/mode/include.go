@@ -0,0 +1,83 @@
+package mode
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// includeDirective matches a line holding an include directive such as
+// "<!-- @include ../snippets/git.md -->"
+var includeDirective = regexp.MustCompile(`^\s*<!--\s*@include\s+(.+?)\s*-->\s*$`)
+
+// expandIncludes replaces include directives in the body of a mode file with the content
+// of the included files
+// Paths are resolved relative to the file containing the directive, included files may
+// include other files, and directives inside fenced code blocks are left as they are.
+// firstLine is the line number of the first line of content in file, and stack lists the
+// files being expanded, to detect cycles.
+func expandIncludes(content, file string, firstLine int, stack []string) (string, Diagnostics) {
+	var diags Diagnostics
+	report := func(line int, format string, args ...interface{}) {
+		diags = append(diags, Diagnostic{
+			File:    file,
+			Line:    firstLine + line,
+			Column:  1,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	lines := strings.Split(content, "\n")
+	inFence := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		m := includeDirective.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		path := m[1]
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(file), path)
+		}
+
+		if slices.Contains(stack, path) {
+			chain := make([]string, 0, len(stack)+1)
+			for _, p := range append(stack, path) {
+				chain = append(chain, filepath.Base(p))
+			}
+			report(i, "include cycle: %s", strings.Join(chain, " -> "))
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			report(i, "included file %q not found", m[1])
+			continue
+		}
+		if err != nil {
+			report(i, "failed to read included file %q: %s", m[1], err)
+			continue
+		}
+
+		nested := make([]string, len(stack), len(stack)+1)
+		copy(nested, stack)
+		included, includedDiags := expandIncludes(string(data), path, 1, append(nested, path))
+		diags = append(diags, includedDiags...)
+
+		lines[i] = strings.TrimSuffix(strings.ReplaceAll(included, "\r\n", "\n"), "\n")
+	}
+
+	return strings.Join(lines, "\n"), diags
+}
@@ -0,0 +1,248 @@
+package mode
+
+// JSRegex is a compiled JavaScript regular expression
+// Matching follows RegExp.prototype.test without flags, using a backtracking matcher.
+// As in JavaScript, strings are matched as UTF-16 code units, so "." matches half of a
+// character outside the Basic Multilingual Plane.
+type JSRegex struct {
+	pattern string
+	parsed  *jsPattern
+}
+
+// CompileJSRegex parses pattern using JavaScript regular expression syntax
+func CompileJSRegex(pattern string) (*JSRegex, error) {
+	parsed, err := parseJSRegex(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &JSRegex{pattern: pattern, parsed: parsed}, nil
+}
+
+// String returns the source pattern
+func (re *JSRegex) String() string {
+	return re.pattern
+}
+
+// MatchString reports whether s contains a match of the regular expression
+func (re *JSRegex) MatchString(s string) bool {
+	input := utf16Units(s)
+	for start := 0; start <= len(input); start++ {
+		m := &jsMatcher{
+			input: input,
+			caps:  make([]int, 2*(re.parsed.groupCount+1)),
+		}
+		for i := range m.caps {
+			m.caps[i] = -1
+		}
+		if m.match(re.parsed.root, start, func(int) bool { return true }) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsMatcher holds the state of a single match attempt
+type jsMatcher struct {
+	input []rune // UTF-16 code units of the string
+	caps  []int  // Start and end offsets of each capturing group, -1 if unset
+}
+
+// match matches node at pos and calls k with the end position of each possible match
+// until k returns true
+func (m *jsMatcher) match(node jsNode, pos int, k func(int) bool) bool {
+	switch n := node.(type) {
+	case *jsSequence:
+		return m.matchSequence(n.terms, pos, k)
+	case *jsDisjunction:
+		for _, alt := range n.alternatives {
+			if m.match(alt, pos, k) {
+				return true
+			}
+		}
+		return false
+	case *jsChar:
+		return pos < len(m.input) && m.input[pos] == n.r && k(pos+1)
+	case *jsAnyChar:
+		return pos < len(m.input) && !isJSLineTerminator(m.input[pos]) && k(pos+1)
+	case *jsCharClass:
+		return pos < len(m.input) && n.matches(m.input[pos]) && k(pos+1)
+	case *jsAssertion:
+		return m.assert(n.kind, pos) && k(pos)
+	case *jsLookaround:
+		return m.matchLookaround(n, pos, k)
+	case *jsGroup:
+		return m.matchGroup(n, pos, k)
+	case *jsBackreference:
+		return m.matchBackreference(n.index, pos, k)
+	case *jsRepeat:
+		return m.matchRepeat(n, 0, pos, k)
+	}
+	return false
+}
+
+func (m *jsMatcher) matchSequence(terms []jsNode, pos int, k func(int) bool) bool {
+	if len(terms) == 0 {
+		return k(pos)
+	}
+	return m.match(terms[0], pos, func(next int) bool {
+		return m.matchSequence(terms[1:], next, k)
+	})
+}
+
+func (m *jsMatcher) assert(kind rune, pos int) bool {
+	switch kind {
+	case '^':
+		return pos == 0
+	case '$':
+		return pos == len(m.input)
+	case 'b':
+		return m.isWordAt(pos-1) != m.isWordAt(pos)
+	case 'B':
+		return m.isWordAt(pos-1) == m.isWordAt(pos)
+	}
+	return false
+}
+
+func (m *jsMatcher) isWordAt(pos int) bool {
+	return pos >= 0 && pos < len(m.input) && isJSWordChar(m.input[pos])
+}
+
+// matchLookaround matches a lookahead or lookbehind
+// Lookarounds are atomic: once the body matched, it is not backtracked into
+func (m *jsMatcher) matchLookaround(n *jsLookaround, pos int, k func(int) bool) bool {
+	saved := append([]int(nil), m.caps...)
+
+	var ok bool
+	if n.behind {
+		// Try every start position that lets the body end exactly at pos
+		for start := pos; start >= 0 && !ok; start-- {
+			ok = m.match(n.body, start, func(end int) bool { return end == pos })
+		}
+	} else {
+		ok = m.match(n.body, pos, func(int) bool { return true })
+	}
+
+	if n.negate {
+		copy(m.caps, saved)
+		return !ok && k(pos)
+	}
+	if ok && k(pos) {
+		return true
+	}
+	copy(m.caps, saved)
+	return false
+}
+
+func (m *jsMatcher) matchGroup(n *jsGroup, pos int, k func(int) bool) bool {
+	if n.index == 0 {
+		return m.match(n.body, pos, k)
+	}
+
+	return m.match(n.body, pos, func(end int) bool {
+		oldStart, oldEnd := m.caps[2*n.index], m.caps[2*n.index+1]
+		m.caps[2*n.index], m.caps[2*n.index+1] = pos, end
+		if k(end) {
+			return true
+		}
+		m.caps[2*n.index], m.caps[2*n.index+1] = oldStart, oldEnd
+		return false
+	})
+}
+
+// matchBackreference matches the text captured by a group
+// A backreference to a group that didn't participate matches the empty string
+func (m *jsMatcher) matchBackreference(index, pos int, k func(int) bool) bool {
+	start, end := m.caps[2*index], m.caps[2*index+1]
+	if start < 0 || end < 0 {
+		return k(pos)
+	}
+
+	length := end - start
+	if pos+length > len(m.input) {
+		return false
+	}
+	for i := 0; i < length; i++ {
+		if m.input[start+i] != m.input[pos+i] {
+			return false
+		}
+	}
+	return k(pos + length)
+}
+
+// matchRepeat matches the remaining iterations of a quantifier after count iterations
+func (m *jsMatcher) matchRepeat(n *jsRepeat, count, pos int, k func(int) bool) bool {
+	iterate := func() bool {
+		saved := append([]int(nil), m.caps...)
+		for i := n.firstGroup; i <= n.lastGroup; i++ {
+			m.caps[2*i], m.caps[2*i+1] = -1, -1
+		}
+
+		ok := m.match(n.body, pos, func(next int) bool {
+			// An iteration that matches the empty string ends the loop once min is reached
+			if next == pos && count >= n.min {
+				return false
+			}
+			return m.matchRepeat(n, count+1, next, k)
+		})
+		if !ok {
+			copy(m.caps, saved)
+		}
+		return ok
+	}
+
+	if count < n.min {
+		return iterate()
+	}
+	if n.max >= 0 && count >= n.max {
+		return k(pos)
+	}
+	if n.greedy {
+		return iterate() || k(pos)
+	}
+	return k(pos) || iterate()
+}
+
+// matches reports whether r belongs to the character class
+func (c *jsCharClass) matches(r rune) bool {
+	for _, item := range c.items {
+		if item.matches(r) {
+			return !c.negate
+		}
+	}
+	return c.negate
+}
+
+func (item jsClassItem) matches(r rune) bool {
+	switch item.escape {
+	case 'd':
+		return r >= '0' && r <= '9'
+	case 'D':
+		return !(r >= '0' && r <= '9')
+	case 's':
+		return isJSWhitespace(r)
+	case 'S':
+		return !isJSWhitespace(r)
+	case 'w':
+		return isJSWordChar(r)
+	case 'W':
+		return !isJSWordChar(r)
+	}
+	return r >= item.lo && r <= item.hi
+}
+
+func isJSWordChar(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_'
+}
+
+func isJSLineTerminator(r rune) bool {
+	return r == '\n' || r == '\r' || r == '\u2028' || r == '\u2029'
+}
+
+func isJSWhitespace(r rune) bool {
+	switch r {
+	case '\t', '\n', '\v', '\f', '\r', ' ', '\u00a0', '\u1680', '\u2028', '\u2029',
+		'\u202f', '\u205f', '\u3000', '\ufeff':
+		return true
+	}
+	return r >= '\u2000' && r <= '\u200a'
+}
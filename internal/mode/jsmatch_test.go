@@ -0,0 +1,80 @@
+package mode
+
+import "testing"
+
+// Expected results are those of new RegExp(pattern).test(input) in Node.js
+func TestJSRegexMatchString(t *testing.T) {
+	tests := []struct {
+		pattern string
+		input   string
+		want    bool
+	}{
+		// Typical fileRegex patterns
+		{`\.md$`, "docs/guide.md", true},
+		{`\.md$`, "docs/guide.mdx", false},
+		{`^src/.*\.ts$`, "src/a/b.ts", true},
+		{`^src/.*\.ts$`, "lib/src/a.ts", false},
+		{`(__tests__/.*|\.test\.(ts|js)$)`, "a/__tests__/x.ts", true},
+		{`(__tests__/.*|\.test\.(ts|js)$)`, "a.test.ts", true},
+		{`(__tests__/.*|\.test\.(ts|js)$)`, "a.test.tsx", false},
+		{`^a|b$`, "xb", true},
+		{`[A-Z]`, "a", false},
+
+		// Lookarounds
+		{`^(?!node_modules/).*\.js$`, "node_modules/a.js", false},
+		{`^(?!node_modules/).*\.js$`, "src/a.js", true},
+		{`(?<!\.d)\.ts$`, "types.d.ts", false},
+		{`(?<!\.d)\.ts$`, "index.ts", true},
+		{`(?<=^src/)\w+\.go$`, "src/main.go", true},
+
+		// Backreferences
+		{`^(\w+)/\1\.md$`, "docs/docs.md", true},
+		{`^(\w+)/\1\.md$`, "docs/api.md", false},
+		{`^(?<d>\w+)-\k<d>$`, "ab-ab", true},
+		{`(a)|\1b`, "b", true},           // unset group matches the empty string
+		{`^(?:(a)|b)+\1$`, "aba", false}, // captures are reset on each iteration
+		{`^(?:(a)|b)+\1$`, "abaa", true},
+		{`^(a?)*$`, "", true},   // empty iterations end the loop
+		{`\k<x>`, "k<x>", true}, // identity escape without named groups
+		{`a{`, "a{", true},      // literal brace (Annex B)
+		{`a{1,`, "xa{1,", true},
+		{`\bfoo\b`, "a foo b", true}, // word boundaries
+		{`\bfoo\b`, "afoo", false},
+		{`.`, "\n", false}, // line terminators
+		{`.`, "\u2028", false},
+		{`^\s$`, "\u00a0", true},            // Unicode whitespace
+		{`\w`, "é", false},                  // ASCII word characters
+		{`\d`, "٣", false},                  // ASCII digits
+		{`^[^/]+\.md$`, "a/b.md", false},    // negated class
+		{`^[\w.\-/]+$`, "src/a-b.ts", true}, // class escapes
+
+		// Strings are UTF-16 code units without the u flag
+		{`^.$`, "😀", false},
+		{`^..$`, "😀", true},
+		{`^.{2}$`, "😀", true},
+		{`^[😀]$`, "😀", false},
+		{`[😀]`, "😀", true},
+		{`^😀$`, "😀", true},
+		{`^\uD83D\uDE00$`, "😀", true},
+		{`^\S$`, "😀", false},
+		{`^é.md$`, "é.md", true},
+	}
+
+	for _, tt := range tests {
+		re, err := CompileJSRegex(tt.pattern)
+		if err != nil {
+			t.Errorf("CompileJSRegex(%q) error = %v", tt.pattern, err)
+			continue
+		}
+		if got := re.MatchString(tt.input); got != tt.want {
+			t.Errorf("/%s/.test(%q) = %v, want %v", tt.pattern, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestCompileJSRegexSurrogateRange(t *testing.T) {
+	// Without the u flag, [😀-😂] is the range \uDE00-\uD83D, which is out of order
+	if _, err := CompileJSRegex(`[😀-😂]`); err == nil {
+		t.Errorf("CompileJSRegex(%q) error = nil, want range out of order", `[😀-😂]`)
+	}
+}
@@ -0,0 +1,761 @@
+package mode
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// RooCode evaluates fileRegex with `new RegExp(fileRegex)` in VS Code's extension host.
+// The parser below follows the ECMAScript pattern grammar without flags, including the
+// web compatibility rules of Annex B that JavaScript engines apply to such patterns.
+// Without the 'u' flag, patterns and input are sequences of UTF-16 code units, so
+// characters outside the Basic Multilingual Plane are two characters to the parser.
+// Constructs that Go accepts but JavaScript rejects or interprets differently are
+// reported with an explanation.
+
+// JSRegexError describes why a pattern is not a valid JavaScript regular expression
+type JSRegexError struct {
+	Pattern  string
+	Position int // 0-based UTF-16 code unit offset of the offending construct
+	Message  string
+}
+
+// Error returns the message prefixed with the 1-based position in the pattern
+func (e *JSRegexError) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Position+1, e.Message)
+}
+
+// ValidateJSRegex checks that pattern is a valid JavaScript regular expression
+func ValidateJSRegex(pattern string) error {
+	_, err := parseJSRegex(pattern)
+	return err
+}
+
+// jsNode is a node of a parsed JavaScript regular expression
+type jsNode interface{}
+
+// jsDisjunction matches any of its alternatives, tried in order
+type jsDisjunction struct {
+	alternatives []jsNode
+}
+
+// jsSequence matches its terms one after another
+type jsSequence struct {
+	terms []jsNode
+}
+
+// jsChar matches a single literal character
+type jsChar struct {
+	r rune
+}
+
+// jsAnyChar matches any character except line terminators (".")
+type jsAnyChar struct{}
+
+// jsClassItem is a character range or a class escape such as \d inside a character class
+type jsClassItem struct {
+	lo, hi rune
+	escape rune // 'd', 'D', 's', 'S', 'w' or 'W' for class escapes, 0 for ranges
+}
+
+// jsCharClass matches one character from a set ("[...]", "\d", ...)
+type jsCharClass struct {
+	negate bool
+	items  []jsClassItem
+}
+
+// jsAssertion matches a position: '^', '$', 'b' (word boundary) or 'B' (non-boundary)
+type jsAssertion struct {
+	kind rune
+}
+
+// jsLookaround matches body at the current position without consuming input
+type jsLookaround struct {
+	behind bool
+	negate bool
+	body   jsNode
+}
+
+// jsGroup groups body, capturing it when index > 0
+type jsGroup struct {
+	index int
+	name  string
+	body  jsNode
+}
+
+// jsBackreference matches the text captured by the group with the given index
+type jsBackreference struct {
+	index int
+}
+
+// jsRepeat matches body between min and max times (max < 0 means unbounded)
+// Captures of groups firstGroup..lastGroup are reset on every iteration
+type jsRepeat struct {
+	body       jsNode
+	min, max   int
+	greedy     bool
+	firstGroup int
+	lastGroup  int
+}
+
+// jsPattern is a parsed JavaScript regular expression
+type jsPattern struct {
+	root       jsNode
+	groupCount int
+}
+
+// jsParser is a recursive descent parser for JavaScript regular expressions
+type jsParser struct {
+	pattern    string
+	src        []rune // UTF-16 code units of the pattern
+	pos        int
+	groupCount int            // Number of capturing groups, from the pre-scan
+	groupNames map[string]int // Named groups and their indexes, from the pre-scan
+	nextGroup  int
+	seenNames  map[string]bool
+}
+
+// parseJSRegex parses pattern using JavaScript regular expression syntax
+func parseJSRegex(pattern string) (*jsPattern, error) {
+	p := &jsParser{
+		pattern:   pattern,
+		src:       utf16Units(pattern),
+		seenNames: map[string]bool{},
+	}
+	p.scanGroups()
+
+	root, err := p.parseDisjunction()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.src) {
+		// The only way to stop before the end is an unbalanced ')'
+		return nil, p.errorf(p.pos, "unmatched ')'")
+	}
+
+	return &jsPattern{root: root, groupCount: p.groupCount}, nil
+}
+
+// scanGroups counts capturing groups and collects group names before parsing,
+// since backreferences may refer to groups defined later in the pattern
+func (p *jsParser) scanGroups() {
+	p.groupNames = map[string]int{}
+	inClass := false
+
+	for i := 0; i < len(p.src); i++ {
+		switch c := p.src[i]; {
+		case c == '\\':
+			i++
+		case inClass:
+			if c == ']' {
+				inClass = false
+			}
+		case c == '[':
+			inClass = true
+		case c == '(':
+			if i+1 < len(p.src) && p.src[i+1] == '?' {
+				if i+2 < len(p.src) && p.src[i+2] == '<' && i+3 < len(p.src) && p.src[i+3] != '=' && p.src[i+3] != '!' {
+					p.groupCount++
+					if end := p.indexFrom(i+3, '>'); end >= 0 {
+						name := p.text(i+3, end)
+						if _, ok := p.groupNames[name]; !ok {
+							p.groupNames[name] = p.groupCount
+						}
+					}
+				}
+				continue
+			}
+			p.groupCount++
+		}
+	}
+}
+
+// text returns the pattern text between code unit offsets i and j
+func (p *jsParser) text(i, j int) string {
+	units := make([]uint16, j-i)
+	for k, r := range p.src[i:j] {
+		units[k] = uint16(r)
+	}
+	return string(utf16.Decode(units))
+}
+
+// indexFrom returns the index of r at or after start, or -1
+func (p *jsParser) indexFrom(start int, r rune) int {
+	for i := start; i < len(p.src); i++ {
+		if p.src[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p *jsParser) errorf(pos int, format string, args ...interface{}) error {
+	return &JSRegexError{Pattern: p.pattern, Position: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *jsParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+// lookingAt reports whether the input at the current position starts with s
+func (p *jsParser) lookingAt(s string) bool {
+	rs := []rune(s)
+	if p.pos+len(rs) > len(p.src) {
+		return false
+	}
+	for i, r := range rs {
+		if p.src[p.pos+i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// parseDisjunction parses alternatives separated by '|'
+func (p *jsParser) parseDisjunction() (jsNode, error) {
+	alt, err := p.parseAlternative()
+	if err != nil {
+		return nil, err
+	}
+
+	alternatives := []jsNode{alt}
+	for !p.eof() && p.src[p.pos] == '|' {
+		p.pos++
+		alt, err := p.parseAlternative()
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, alt)
+	}
+
+	if len(alternatives) == 1 {
+		return alternatives[0], nil
+	}
+	return &jsDisjunction{alternatives: alternatives}, nil
+}
+
+// parseAlternative parses terms until '|', ')' or the end of the pattern
+func (p *jsParser) parseAlternative() (jsNode, error) {
+	seq := &jsSequence{}
+	for !p.eof() && p.src[p.pos] != '|' && p.src[p.pos] != ')' {
+		term, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		seq.terms = append(seq.terms, term)
+	}
+	return seq, nil
+}
+
+// parseTerm parses an assertion or an atom with an optional quantifier
+func (p *jsParser) parseTerm() (jsNode, error) {
+	start := p.pos
+	firstGroup := p.nextGroup + 1
+
+	switch {
+	case p.lookingAt("^"), p.lookingAt("$"):
+		p.pos++
+		return p.noQuantifier(&jsAssertion{kind: p.src[start]})
+	case p.lookingAt(`\b`), p.lookingAt(`\B`):
+		p.pos += 2
+		return p.noQuantifier(&jsAssertion{kind: p.src[start+1]})
+	case p.lookingAt("(?<="), p.lookingAt("(?<!"):
+		// Lookbehinds can't be quantified
+		p.pos += 4
+		body, err := p.parseGroupBody(start)
+		if err != nil {
+			return nil, err
+		}
+		return p.noQuantifier(&jsLookaround{behind: true, negate: p.src[start+3] == '!', body: body})
+	case p.lookingAt("(?="), p.lookingAt("(?!"):
+		// Lookaheads can be quantified in Annex B
+		p.pos += 3
+		body, err := p.parseGroupBody(start)
+		if err != nil {
+			return nil, err
+		}
+		return p.parseQuantifier(&jsLookaround{negate: p.src[start+2] == '!', body: body}, firstGroup)
+	}
+
+	switch p.src[p.pos] {
+	case '*', '+', '?':
+		return nil, p.errorf(p.pos, "nothing to repeat before '%c'", p.src[p.pos])
+	case '{':
+		if _, _, end := p.scanBraceQuantifier(); end > 0 {
+			return nil, p.errorf(p.pos, "nothing to repeat before '{'")
+		}
+	}
+
+	atom, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	return p.parseQuantifier(atom, firstGroup)
+}
+
+// noQuantifier returns node, or an error if it is followed by a quantifier
+func (p *jsParser) noQuantifier(node jsNode) (jsNode, error) {
+	if p.eof() {
+		return node, nil
+	}
+	switch p.src[p.pos] {
+	case '*', '+', '?':
+		return nil, p.errorf(p.pos, "nothing to repeat before '%c'", p.src[p.pos])
+	case '{':
+		if _, _, end := p.scanBraceQuantifier(); end > 0 {
+			return nil, p.errorf(p.pos, "nothing to repeat before '{'")
+		}
+	}
+	return node, nil
+}
+
+// parseQuantifier wraps atom in a jsRepeat if a quantifier follows
+func (p *jsParser) parseQuantifier(atom jsNode, firstGroup int) (jsNode, error) {
+	if p.eof() {
+		return atom, nil
+	}
+
+	start := p.pos
+	var minCount, maxCount int
+	switch p.src[p.pos] {
+	case '*':
+		minCount, maxCount = 0, -1
+		p.pos++
+	case '+':
+		minCount, maxCount = 1, -1
+		p.pos++
+	case '?':
+		minCount, maxCount = 0, 1
+		p.pos++
+	case '{':
+		var end int
+		minCount, maxCount, end = p.scanBraceQuantifier()
+		if end < 0 {
+			// Not a quantifier; Annex B treats '{' as a literal
+			return atom, nil
+		}
+		if maxCount >= 0 && minCount > maxCount {
+			return nil, p.errorf(start, "numbers out of order in {} quantifier")
+		}
+		p.pos = end
+	default:
+		return atom, nil
+	}
+
+	greedy := true
+	if !p.eof() && p.src[p.pos] == '?' {
+		greedy = false
+		p.pos++
+	}
+
+	if !p.eof() {
+		switch p.src[p.pos] {
+		case '*', '+', '?':
+			return nil, p.errorf(p.pos, "nothing to repeat before '%c' (possessive and stacked quantifiers are not supported in JavaScript)", p.src[p.pos])
+		}
+	}
+
+	return &jsRepeat{
+		body:       atom,
+		min:        minCount,
+		max:        maxCount,
+		greedy:     greedy,
+		firstGroup: firstGroup,
+		lastGroup:  p.nextGroup,
+	}, nil
+}
+
+// scanBraceQuantifier reads {n}, {n,} or {n,m} at the current position without consuming it
+// Returns end < 0 if the input is not a brace quantifier
+func (p *jsParser) scanBraceQuantifier() (minCount, maxCount, end int) {
+	i := p.pos + 1
+	readInt := func() (int, bool) {
+		start := i
+		n := 0
+		for i < len(p.src) && p.src[i] >= '0' && p.src[i] <= '9' {
+			if n < 1<<20 {
+				n = n*10 + int(p.src[i]-'0')
+			}
+			i++
+		}
+		return n, i > start
+	}
+
+	minCount, ok := readInt()
+	if !ok {
+		return 0, 0, -1
+	}
+	maxCount = minCount
+	if i < len(p.src) && p.src[i] == ',' {
+		i++
+		if maxCount, ok = readInt(); !ok {
+			maxCount = -1
+		}
+	}
+	if i >= len(p.src) || p.src[i] != '}' {
+		return 0, 0, -1
+	}
+	return minCount, maxCount, i + 1
+}
+
+// parseAtom parses a single atom: a character, class, group or escape
+func (p *jsParser) parseAtom() (jsNode, error) {
+	c := p.src[p.pos]
+	switch c {
+	case '.':
+		p.pos++
+		return &jsAnyChar{}, nil
+	case '(':
+		return p.parseGroup()
+	case '[':
+		return p.parseCharClass()
+	case '\\':
+		return p.parseAtomEscape()
+	}
+	// ']', '{' and '}' are literals in Annex B
+	p.pos++
+	return &jsChar{r: c}, nil
+}
+
+// parseGroup parses a capturing, named or non-capturing group
+func (p *jsParser) parseGroup() (jsNode, error) {
+	start := p.pos
+
+	switch {
+	case p.lookingAt("(?:"):
+		p.pos += 3
+		body, err := p.parseGroupBody(start)
+		if err != nil {
+			return nil, err
+		}
+		return &jsGroup{body: body}, nil
+	case p.lookingAt("(?P<"):
+		return nil, p.errorf(start, "named groups (?P<name>...) are Go syntax; JavaScript uses (?<name>...)")
+	case p.lookingAt("(?P="), p.lookingAt("(?P>"):
+		return nil, p.errorf(start, "(?P=name) backreferences are not supported in JavaScript; use \\k<name>")
+	case p.lookingAt("(?<"):
+		p.pos += 3
+		nameStart := p.pos
+		end := p.indexFrom(p.pos, '>')
+		if end < 0 {
+			return nil, p.errorf(nameStart, "invalid capture group name")
+		}
+		name := p.text(nameStart, end)
+		if !isJSIdentifier(name) {
+			return nil, p.errorf(nameStart, "invalid capture group name %q", name)
+		}
+		if p.seenNames[name] {
+			return nil, p.errorf(nameStart, "duplicate capture group name %q", name)
+		}
+		p.seenNames[name] = true
+		p.pos = end + 1
+		p.nextGroup++
+		index := p.nextGroup
+		body, err := p.parseGroupBody(start)
+		if err != nil {
+			return nil, err
+		}
+		return &jsGroup{index: index, name: name, body: body}, nil
+	case p.lookingAt("(?"):
+		// Go and PCRE inline flags such as (?i) or (?i:...)
+		i := p.pos + 2
+		for i < len(p.src) && strings.ContainsRune("imsU-", p.src[i]) {
+			i++
+		}
+		if i > p.pos+2 && i < len(p.src) && p.src[i] == ')' {
+			return nil, p.errorf(start, "inline flags (?%s) are Go syntax and not supported in JavaScript", p.text(p.pos+2, i))
+		}
+		if i > p.pos+2 && i < len(p.src) && p.src[i] == ':' {
+			return nil, p.errorf(start, "inline flag groups (?%s:...) are Go syntax and not supported by the JavaScript engine RooCode runs on", p.text(p.pos+2, i))
+		}
+		return nil, p.errorf(start, "invalid group")
+	}
+
+	p.pos++
+	p.nextGroup++
+	index := p.nextGroup
+	body, err := p.parseGroupBody(start)
+	if err != nil {
+		return nil, err
+	}
+	return &jsGroup{index: index, body: body}, nil
+}
+
+// parseGroupBody parses a disjunction followed by the closing ')' of the group opened at start
+func (p *jsParser) parseGroupBody(start int) (jsNode, error) {
+	body, err := p.parseDisjunction()
+	if err != nil {
+		return nil, err
+	}
+	if p.eof() {
+		return nil, p.errorf(start, "unterminated group")
+	}
+	p.pos++ // ')'
+	return body, nil
+}
+
+// parseAtomEscape parses an escape sequence outside a character class
+func (p *jsParser) parseAtomEscape() (jsNode, error) {
+	start := p.pos
+	p.pos++ // '\'
+	if p.eof() {
+		return nil, p.errorf(start, "\\ at end of pattern")
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case strings.ContainsRune("dDsSwW", c):
+		p.pos++
+		return &jsCharClass{items: []jsClassItem{{escape: c}}}, nil
+	case c >= '1' && c <= '9':
+		// Backreference if the number refers to an existing group,
+		// otherwise a legacy octal escape or an identity escape (Annex B)
+		i := p.pos
+		n := 0
+		for i < len(p.src) && p.src[i] >= '0' && p.src[i] <= '9' && n <= p.groupCount {
+			n = n*10 + int(p.src[i]-'0')
+			i++
+		}
+		if n <= p.groupCount {
+			p.pos = i
+			return &jsBackreference{index: n}, nil
+		}
+	case c == 'k' && len(p.groupNames) > 0:
+		// \k<name> is only a named backreference if the pattern has named groups
+		p.pos++
+		if p.eof() || p.src[p.pos] != '<' {
+			return nil, p.errorf(start, "invalid named reference")
+		}
+		end := p.indexFrom(p.pos, '>')
+		if end < 0 {
+			return nil, p.errorf(start, "invalid named reference")
+		}
+		name := p.text(p.pos+1, end)
+		index, ok := p.groupNames[name]
+		if !ok {
+			return nil, p.errorf(start, "invalid named capture referenced %q", name)
+		}
+		p.pos = end + 1
+		return &jsBackreference{index: index}, nil
+	}
+
+	r, err := p.parseCharacterEscape(start, false)
+	if err != nil {
+		return nil, err
+	}
+	return &jsChar{r: r}, nil
+}
+
+// parseCharacterEscape parses the character escape after '\' at start
+// The current position is the character following the backslash
+func (p *jsParser) parseCharacterEscape(start int, inClass bool) (rune, error) {
+	c := p.src[p.pos]
+
+	switch c {
+	case 'f':
+		p.pos++
+		return '\f', nil
+	case 'n':
+		p.pos++
+		return '\n', nil
+	case 'r':
+		p.pos++
+		return '\r', nil
+	case 't':
+		p.pos++
+		return '\t', nil
+	case 'v':
+		p.pos++
+		return '\v', nil
+	case 'c':
+		if p.pos+1 < len(p.src) {
+			l := p.src[p.pos+1]
+			if (l >= 'a' && l <= 'z') || (l >= 'A' && l <= 'Z') || (inClass && (l == '_' || (l >= '0' && l <= '9'))) {
+				p.pos += 2
+				return l % 32, nil
+			}
+		}
+		// Annex B: "\c" without a control letter matches a backslash,
+		// and the 'c' is parsed as a literal on its own
+		return '\\', nil
+	case 'x':
+		if p.pos+1 < len(p.src) && p.src[p.pos+1] == '{' {
+			return 0, p.errorf(start, "\\x{...} escapes are Go syntax; JavaScript uses \\xHH or \\uHHHH")
+		}
+		if v, ok := p.hexDigits(p.pos+1, 2); ok {
+			p.pos += 3
+			return v, nil
+		}
+	case 'u':
+		if p.pos+1 < len(p.src) && p.src[p.pos+1] == '{' {
+			return 0, p.errorf(start, "\\u{...} escapes require the 'u' flag, which RooCode doesn't set; use \\uHHHH")
+		}
+		if v, ok := p.hexDigits(p.pos+1, 4); ok {
+			p.pos += 5
+			return v, nil
+		}
+	case 'p', 'P':
+		return 0, p.errorf(start, "Unicode classes \\%c{...} require the 'u' flag, which RooCode doesn't set", c)
+	case 'A':
+		return 0, p.errorf(start, "\\A is Go syntax and matches a literal 'A' in JavaScript; use ^")
+	case 'z':
+		return 0, p.errorf(start, "\\z is Go syntax and matches a literal 'z' in JavaScript; use $")
+	case 'Q', 'E':
+		return 0, p.errorf(start, "\\Q...\\E quoting is Go syntax; escape each character instead")
+	case 'C':
+		return 0, p.errorf(start, "\\C is Go syntax and matches a literal 'C' in JavaScript")
+	case '0', '1', '2', '3', '4', '5', '6', '7':
+		// "\0" alone is NUL, otherwise a legacy octal escape (Annex B)
+		v := rune(0)
+		i := p.pos
+		for i < len(p.src) && i < p.pos+3 && p.src[i] >= '0' && p.src[i] <= '7' && v*8+(p.src[i]-'0') <= 0377 {
+			v = v*8 + (p.src[i] - '0')
+			i++
+		}
+		p.pos = i
+		return v, nil
+	}
+
+	// Identity escape
+	p.pos++
+	return c, nil
+}
+
+// hexDigits reads n hexadecimal digits at index i
+func (p *jsParser) hexDigits(i, n int) (rune, bool) {
+	if i+n > len(p.src) {
+		return 0, false
+	}
+	v := rune(0)
+	for _, r := range p.src[i : i+n] {
+		switch {
+		case r >= '0' && r <= '9':
+			v = v*16 + r - '0'
+		case r >= 'a' && r <= 'f':
+			v = v*16 + r - 'a' + 10
+		case r >= 'A' && r <= 'F':
+			v = v*16 + r - 'A' + 10
+		default:
+			return 0, false
+		}
+	}
+	return v, true
+}
+
+// parseCharClass parses a character class "[...]"
+func (p *jsParser) parseCharClass() (jsNode, error) {
+	start := p.pos
+	p.pos++ // '['
+
+	class := &jsCharClass{}
+	if !p.eof() && p.src[p.pos] == '^' {
+		class.negate = true
+		p.pos++
+	}
+
+	for {
+		if p.eof() {
+			return nil, p.errorf(start, "unterminated character class")
+		}
+		if p.src[p.pos] == ']' {
+			p.pos++
+			return class, nil
+		}
+
+		if p.lookingAt("[:") {
+			if end := p.indexFrom(p.pos+2, ']'); end > 0 && p.src[end-1] == ':' {
+				return nil, p.errorf(p.pos, "POSIX character classes like %s are Go syntax and not supported in JavaScript", p.text(p.pos, end+1))
+			}
+		}
+
+		lo, err := p.parseClassAtom()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.pos+1 < len(p.src) && p.src[p.pos] == '-' && p.src[p.pos+1] != ']' {
+			rangeStart := p.pos
+			p.pos++ // '-'
+			hi, err := p.parseClassAtom()
+			if err != nil {
+				return nil, err
+			}
+
+			if lo.escape != 0 || hi.escape != 0 {
+				// Annex B: a range with a class escape is a union of both ends and '-'
+				class.items = append(class.items, lo, jsClassItem{lo: '-', hi: '-'}, hi)
+				continue
+			}
+			if lo.lo > hi.lo {
+				return nil, p.errorf(rangeStart, "range out of order in character class")
+			}
+			class.items = append(class.items, jsClassItem{lo: lo.lo, hi: hi.lo})
+			continue
+		}
+
+		class.items = append(class.items, lo)
+	}
+}
+
+// parseClassAtom parses a single character or class escape inside a character class
+func (p *jsParser) parseClassAtom() (jsClassItem, error) {
+	c := p.src[p.pos]
+	if c != '\\' {
+		p.pos++
+		return jsClassItem{lo: c, hi: c}, nil
+	}
+
+	start := p.pos
+	p.pos++
+	if p.eof() {
+		return jsClassItem{}, p.errorf(start, "\\ at end of pattern")
+	}
+
+	c = p.src[p.pos]
+	switch {
+	case strings.ContainsRune("dDsSwW", c):
+		p.pos++
+		return jsClassItem{escape: c}, nil
+	case c == 'b':
+		p.pos++
+		return jsClassItem{lo: '\b', hi: '\b'}, nil
+	case c == '-':
+		p.pos++
+		return jsClassItem{lo: '-', hi: '-'}, nil
+	}
+
+	r, err := p.parseCharacterEscape(start, true)
+	if err != nil {
+		return jsClassItem{}, err
+	}
+	return jsClassItem{lo: r, hi: r}, nil
+}
+
+// isJSIdentifier reports whether name is a valid capture group name
+func isJSIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '$' || r == '_' || unicode.IsLetter(r):
+		case i > 0 && unicode.IsDigit(r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// utf16Units returns the UTF-16 code units of s, one per rune
+func utf16Units(s string) []rune {
+	units := utf16.Encode([]rune(s))
+	runes := make([]rune, len(units))
+	for i, u := range units {
+		runes[i] = rune(u)
+	}
+	return runes
+}
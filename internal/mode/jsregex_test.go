@@ -0,0 +1,112 @@
+package mode
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateJSRegexValid(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+	}{
+		{"literal", `\.md$`},
+		{"alternation and groups", `^(src|test)/.*\.(ts|tsx)$`},
+		{"non-capturing group", `(?:__tests__|__mocks__)/`},
+		{"lookahead", `foo(?=bar)`},
+		{"negative lookahead", `^(?!node_modules/).*\.js$`},
+		{"lookbehind", `(?<=src/)\w+`},
+		{"negative lookbehind", `(?<!\.d)\.ts$`},
+		{"quantified lookahead (Annex B)", `a(?=b)*`},
+		{"numbered backreference", `(a)\1`},
+		{"backreference to a later group", `\1(a)`},
+		{"named group and backreference", `(?<dir>\w+)/\k<dir>`},
+		{"\\k without named groups is an identity escape (Annex B)", `\k<dir>`},
+		{"literal { without quantifier (Annex B)", `a{`},
+		{"literal { with incomplete quantifier (Annex B)", `a{1,`},
+		{"literal braces (Annex B)", `{}`},
+		{"literal { with non-digits (Annex B)", `x{a}`},
+		{"literal ] (Annex B)", `]`},
+		{"brace quantifiers", `a{2}b{1,}c{1,3}?`},
+		{"lazy quantifiers", `a*?b+?c??`},
+		{"character class with escapes", `[\w.\-/]+`},
+		{"class escape in range (Annex B)", `[\d-z]`},
+		{"negated class", `[^/]+\.md$`},
+		{"hex and unicode escapes", `\x41B`},
+		{"control escape", `\cJ`},
+		{"\\c without control letter (Annex B)", `\c`},
+		{"NUL and legacy octal escapes (Annex B)", `\0\07\101`},
+		{"number above group count is an octal escape (Annex B)", `(a)\2`},
+		{"escaped slash", `docs\/.*`},
+		{"word boundaries", `\bfoo\B`},
+		{"empty pattern", ``},
+		{"empty alternative", `a|`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateJSRegex(tt.pattern); err != nil {
+				t.Errorf("ValidateJSRegex(%q) error = %v, want nil", tt.pattern, err)
+			}
+		})
+	}
+}
+
+func TestValidateJSRegexInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		position int
+		message  string
+	}{
+		// Go syntax that JavaScript rejects or reads differently
+		{"Go named group", `(?P<name>a)`, 0, "(?P<name>...) are Go syntax"},
+		{"Go named backreference", `(?P<x>a)(?P=x)`, 0, "(?P<name>...) are Go syntax"},
+		{"Go named backreference alone", `(?P=x)`, 0, "use \\k<name>"},
+		{"inline flags", `(?i)abc`, 0, "inline flags (?i) are Go syntax"},
+		{"inline flag group", `(?i:abc)`, 0, "inline flag groups (?i:...) are Go syntax"},
+		{"\\x{...} escape", `\x{41}`, 0, "\\x{...} escapes are Go syntax"},
+		{"\\u{...} requires the u flag", `\u{1F600}`, 0, "require the 'u' flag"},
+		{"Unicode class requires the u flag", `\p{L}`, 0, "\\p{...} require the 'u' flag"},
+		{"negated Unicode class requires the u flag", `a\P{L}`, 1, "\\P{...} require the 'u' flag"},
+		{"\\A", `\Afoo`, 0, "\\A is Go syntax"},
+		{"\\z", `foo\z`, 3, "\\z is Go syntax"},
+		{"\\Q...\\E quoting", `\Qa.b\E`, 0, "\\Q...\\E quoting is Go syntax"},
+		{"\\C", `a\C`, 1, "\\C is Go syntax"},
+		{"POSIX class", `[[:alpha:]]`, 1, "POSIX character classes like [:alpha:]"},
+		{"possessive quantifier", `a++`, 2, "possessive and stacked quantifiers"},
+		{"stacked quantifier", `a*+`, 2, "possessive and stacked quantifiers"},
+		{"\\u{...} in a class", `[\u{41}]`, 1, "require the 'u' flag"},
+
+		// Syntax errors
+		{"nothing to repeat", `*a`, 0, "nothing to repeat before '*'"},
+		{"nothing to repeat before {", `{2}`, 0, "nothing to repeat before '{'"},
+		{"quantified assertion", `^*`, 1, "nothing to repeat before '*'"},
+		{"quantified lookbehind", `(?<=a)*`, 6, "nothing to repeat before '*'"},
+		{"quantifier out of order", `a{2,1}`, 1, "numbers out of order"},
+		{"class range out of order", `[b-a]`, 2, "range out of order"},
+		{"unterminated group", `(a`, 0, "unterminated group"},
+		{"unmatched )", `a)`, 1, "unmatched ')'"},
+		{"unterminated class", `[a`, 0, "unterminated character class"},
+		{"trailing backslash", `a\`, 1, "\\ at end of pattern"},
+		{"invalid group", `(?x)`, 0, "invalid group"},
+		{"invalid group name", `(?<1a>x)`, 3, "invalid capture group name"},
+		{"duplicate group name", `(?<a>x)(?<a>y)`, 10, "duplicate capture group name"},
+		{"unknown named reference", `(?<a>x)\k<b>`, 7, "invalid named capture referenced"},
+		{"incomplete named reference", `(?<a>x)\k`, 7, "invalid named reference"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateJSRegex(tt.pattern)
+			var jsErr *JSRegexError
+			if !errors.As(err, &jsErr) {
+				t.Fatalf("ValidateJSRegex(%q) error = %v, want a JSRegexError", tt.pattern, err)
+			}
+			if jsErr.Position != tt.position || !strings.Contains(jsErr.Message, tt.message) {
+				t.Errorf("ValidateJSRegex(%q) error at %d: %q, want at %d containing %q", tt.pattern, jsErr.Position, jsErr.Message, tt.position, tt.message)
+			}
+		})
+	}
+}
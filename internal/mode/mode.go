@@ -2,8 +2,8 @@ package mode
 
 // GroupOptions defines options for tool groups (like file access restrictions)
 type GroupOptions struct {
-	FileRegex   *string `yaml:"fileRegex,omitempty" json:"fileRegex,omitempty"`
-	Description *string `yaml:"description,omitempty" json:"description,omitempty"`
+	FileRegex   *string `yaml:"fileRegex,omitempty" json:"fileRegex,omitempty" jsonschema_description:"JavaScript regular expression for the files the group may access"`
+	Description *string `yaml:"description,omitempty" json:"description,omitempty" jsonschema_description:"Description of the files matched by fileRegex"`
 }
 
 // GroupEntry represents either a simple group name (string) or a [string, GroupOptions] tuple
@@ -12,22 +12,49 @@ type GroupEntry interface{}
 
 // Metadata represents data parsed from frontmatter
 type Metadata struct {
-	Name           string       `yaml:"name" json:"name"`
-	Groups         []GroupEntry `yaml:"groups" json:"groups"` // Requires custom parsing after initial parse
-	RoleDefinition string       `yaml:"roleDefinition" json:"roleDefinition"`
-	Source         string       `yaml:"source,omitempty" json:"source,omitempty"`
+	Name           string                 `yaml:"name" json:"name" jsonschema:"required,minLength=1" jsonschema_description:"Display name of the mode"`
+	Groups         []GroupEntry           `yaml:"groups" json:"groups" jsonschema:"required" jsonschema_description:"Tool groups the mode can use"` // Requires custom parsing after initial parse
+	RoleDefinition string                 `yaml:"roleDefinition" json:"roleDefinition" jsonschema:"required,minLength=1" jsonschema_description:"Role definition placed at the start of the system prompt"`
+	WhenToUse      string                 `yaml:"whenToUse,omitempty" json:"whenToUse,omitempty" jsonschema_description:"When the mode should be used; the Orchestrator uses it to pick a mode to delegate to"`
+	Description    string                 `yaml:"description,omitempty" json:"description,omitempty" jsonschema_description:"Short description of the mode shown in the mode selector"`
+	Source         string                 `yaml:"source,omitempty" json:"source,omitempty" jsonschema:"enum=global|project" jsonschema_description:"Where the mode is defined"`
+	Extends        string                 `yaml:"extends,omitempty" json:"extends,omitempty" jsonschema_description:"Slug of a mode in the same directory to inherit groups, role definition and instructions from"`
+	Template       bool                   `yaml:"template,omitempty" json:"template,omitempty" jsonschema_description:"Render roleDefinition and the instructions as Go text/template templates at export"`
+	Vars           map[string]interface{} `yaml:"vars,omitempty" json:"vars,omitempty" jsonschema_description:"Values available to templates as .Vars; declaring vars enables templating"`
+	Strict         *bool                  `yaml:"strict,omitempty" json:"strict,omitempty" jsonschema_description:"Set to false to allow unknown keys"`
 }
 
 // Config represents the complete data for a custom mode
 type Config struct {
 	Slug               string
 	Name               string
-	GroupsRaw          []GroupEntry       // Raw data from frontmatter
-	GroupsParsed       []ParsedGroupEntry // Parsed and validated groups
-	RoleDefinition     string             // From frontmatter
-	CustomInstructions *string            // Content of the Markdown body (if not empty)
-	FilePath           string             // Path to the source Markdown file
-	Source             string             // Original source path from frontmatter
+	GroupsRaw          []GroupEntry           // Raw data from frontmatter
+	GroupsParsed       []ParsedGroupEntry     // Parsed and validated groups
+	RoleDefinition     string                 // From frontmatter
+	WhenToUse          string                 // When the mode should be used, from frontmatter
+	Description        string                 // Short description of the mode, from frontmatter
+	CustomInstructions *string                // Content of the Markdown body (if not empty)
+	FilePath           string                 // Path to the source Markdown file
+	Source             string                 // Original source path from frontmatter
+	Extends            string                 // Slug of the mode this mode extends, from frontmatter
+	Template           bool                   // Whether roleDefinition and instructions are templates
+	Vars               map[string]interface{} // Template variables, from frontmatter
+	Positions          map[string]Position    // Source positions of frontmatter fields
+	Keys               map[string]bool        // Top-level frontmatter keys present in the file, in any format
+	BodyLine           int                    // Line number of the first line after the frontmatter
+	Includes           bool                   // Whether the body includes other files
+
+	// groupIndexes holds the index in the file of each parsed group when some groups
+	// couldn't be parsed, and is nil otherwise
+	groupIndexes []int
+}
+
+// groupIndex returns the index in the file of the parsed group at index i
+func (c *Config) groupIndex(i int) int {
+	if c.groupIndexes != nil {
+		return c.groupIndexes[i]
+	}
+	return i
 }
 
 // ParsedGroupEntry represents a validated group entry
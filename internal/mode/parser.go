@@ -2,15 +2,17 @@ package mode
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
-
-	"github.com/adrg/frontmatter"
 )
 
 // ParseModeFile parses a specified Markdown file and returns a Config
+// Unknown keys, invalid groups and includes are returned as Diagnostics together with the rest
+// of the Config
 func ParseModeFile(filePath string) (*Config, error) {
 
 	absPath, err := filepath.Abs(filePath)
@@ -24,21 +26,42 @@ func ParseModeFile(filePath string) (*Config, error) {
 	}
 
 	var metadata Metadata
-	content, err := frontmatter.Parse(bytes.NewReader(data), &metadata)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse frontmatter: %w", err)
+	var positions map[string]Position
+	var keys map[string]bool
+	var unknown Diagnostics
+	var content []byte
+
+	if fm, ok := splitFrontmatter(data); ok {
+		// Frontmatter is decoded through yaml.v3 nodes, which keep source positions for YAML
+		positions, keys, unknown, err = decodeFrontmatter(fm, &metadata)
+		if err != nil {
+			var diags Diagnostics
+			if errors.As(err, &diags) {
+				err = diags.locate(absPath, positions)
+			}
+			return nil, fmt.Errorf("failed to parse frontmatter: %w", err)
+		}
+		content = fm.body
+	} else {
+		// Without frontmatter, the whole file is the body
+		content = data
 	}
 
 	base := filepath.Base(absPath)
 	slug := base[:len(base)-len(filepath.Ext(base))]
 
-	parsedGroups, err := ParseGroupEntries(metadata.Groups)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse group entries: %w", err)
-	}
+	// Unknown keys, invalid groups and includes don't stop parsing, so the rest of the mode can
+	// be validated
+	parsedGroups, groupIndexes, groupDiags := parseGroupEntries(metadata.Groups)
+	diags := append(unknown, groupDiags...).locate(absPath, positions)
 
-	// Convert content to string and check if it's empty or just whitespace
-	contentStr := string(content)
+	// Expand include directives in the body
+	bodyLine := bytes.Count(data[:len(data)-len(content)], []byte("\n")) + 1
+	contentStr, includeDiags := expandIncludes(string(content), absPath, bodyLine, []string{absPath})
+	diags = append(diags, includeDiags...)
+	hasIncludes := contentStr != string(content)
+
+	// Check if content is empty or just whitespace
 	contentStr = strings.TrimSpace(contentStr)
 
 	// If content is not empty, use it as CustomInstructions
@@ -53,215 +76,250 @@ func ParseModeFile(filePath string) (*Config, error) {
 		GroupsRaw:          metadata.Groups,
 		GroupsParsed:       parsedGroups,
 		RoleDefinition:     metadata.RoleDefinition,
+		WhenToUse:          metadata.WhenToUse,
+		Description:        metadata.Description,
 		CustomInstructions: customInstructions,
 		FilePath:           absPath,
 		Source:             metadata.Source,
+		Extends:            metadata.Extends,
+		Template:           metadata.Template,
+		Vars:               metadata.Vars,
+		Positions:          positions,
+		Keys:               keys,
+		BodyLine:           bodyLine,
+		Includes:           hasIncludes,
+		groupIndexes:       groupIndexes,
 	}
 
+	if len(diags) > 0 {
+		return config, diags
+	}
 	return config, nil
 }
 
 // ParseGroupEntries parses and validates raw GroupEntry slices
+// All invalid entries are reported, not only the first one
 func ParseGroupEntries(rawGroups []GroupEntry) ([]ParsedGroupEntry, error) {
+	parsedGroups, _, diags := parseGroupEntries(rawGroups)
+	if err := diags.errorOrNil(); err != nil {
+		return nil, err
+	}
+	return parsedGroups, nil
+}
+
+// parseGroupEntries parses the valid entries of rawGroups and reports the invalid ones
+// If any entry is invalid, the index of each parsed entry in rawGroups is returned too
+func parseGroupEntries(rawGroups []GroupEntry) ([]ParsedGroupEntry, []int, Diagnostics) {
 	parsedGroups := make([]ParsedGroupEntry, 0, len(rawGroups))
+	indexes := make([]int, 0, len(rawGroups))
+	seen := make(map[string]int, len(rawGroups))
+	var diags Diagnostics
 
 	for i, entry := range rawGroups {
-		switch v := entry.(type) {
-		case string:
-			// Simple string group
-			parsedGroups = append(parsedGroups, ParsedGroupEntry{
-				Name:    v,
-				Options: nil,
+		group, err := parseGroupEntry(i, entry)
+		if err != nil {
+			diags = append(diags, Diagnostic{
+				Field:   fmt.Sprintf("groups[%d]", i),
+				Message: err.Error(),
 			})
-		case []interface{}:
-			// Array format [string, options]
-			if len(v) != 2 {
-				return nil, fmt.Errorf("invalid group entry at index %d: array must have exactly 2 elements", i)
-			}
-
-			name, ok := v[0].(string)
-			if !ok {
-				return nil, fmt.Errorf("invalid group entry at index %d: first element must be a string", i)
-			}
-
-			// Handle different types of option maps
-			options := &GroupOptions{}
-
-			switch optMap := v[1].(type) {
-			case map[interface{}]interface{}:
-				// YAML parsing typically produces map[interface{}]interface{}
-				if fileRegex, ok := optMap["fileRegex"]; ok {
-					if fileRegexStr, ok := fileRegex.(string); ok {
-						options.FileRegex = &fileRegexStr
-					} else {
-						return nil, fmt.Errorf("invalid fileRegex at index %d: must be a string", i)
-					}
-				}
-
-				if description, ok := optMap["description"]; ok {
-					if descriptionStr, ok := description.(string); ok {
-						options.Description = &descriptionStr
-					} else {
-						return nil, fmt.Errorf("invalid description at index %d: must be a string", i)
-					}
-				}
-			case map[string]interface{}:
-				// JSON parsing typically produces map[string]interface{}
-				if fileRegex, ok := optMap["fileRegex"]; ok {
-					if fileRegexStr, ok := fileRegex.(string); ok {
-						options.FileRegex = &fileRegexStr
-					} else {
-						return nil, fmt.Errorf("invalid fileRegex at index %d: must be a string", i)
-					}
-				}
-
-				if description, ok := optMap["description"]; ok {
-					if descriptionStr, ok := description.(string); ok {
-						options.Description = &descriptionStr
-					} else {
-						return nil, fmt.Errorf("invalid description at index %d: must be a string", i)
-					}
-				}
-			case string:
-				// Handle case where the second element is a string representation of a map
-				// This happens sometimes with YAML parsing of complex structures
-				if optMap == "" {
-					// Empty options
-					options = nil
-				} else if strings.HasPrefix(optMap, "map[") {
-					// Try to parse map[key:value] format
-					optStr := optMap
-					// Remove the map[] wrapper
-					optStr = strings.TrimPrefix(optStr, "map[")
-					optStr = strings.TrimSuffix(optStr, "]")
-
-					// Split by space to get key-value pairs
-					parts := strings.Split(optStr, " ")
-					for _, part := range parts {
-						if part == "" {
-							continue
-						}
-
-						keyValue := strings.SplitN(part, ":", 2)
-						if len(keyValue) != 2 {
-							continue
-						}
-
-						key := keyValue[0]
-						value := keyValue[1]
-
-						switch key {
-						case "fileRegex":
-							options.FileRegex = &value
-						case "description":
-							options.Description = &value
-						}
-					}
-				} else {
-					return nil, fmt.Errorf("invalid options format at index %d: %s", i, optMap)
-				}
-			default:
-				return nil, fmt.Errorf("invalid options format at index %d: %T", i, v[1])
-			}
+			continue
+		}
 
-			parsedGroups = append(parsedGroups, ParsedGroupEntry{
-				Name:    name,
-				Options: options,
+		// The same group may only be listed once
+		if first, ok := seen[group.Name]; ok {
+			diags = append(diags, Diagnostic{
+				Field:   fmt.Sprintf("groups[%d]", i),
+				Message: fmt.Sprintf("duplicate group %q at index %d (already listed at index %d)", group.Name, i, first),
 			})
-		case map[interface{}]interface{}:
-			// Handle YAML object format with key as group name and value as options
-			// This format looks like: - groupName: { options }
-			if len(v) != 1 {
-				return nil, fmt.Errorf("invalid group entry at index %d: map must have exactly 1 key", i)
-			}
+			continue
+		}
+		seen[group.Name] = i
 
-			// Extract the single key (group name) and value (options)
-			var name string
-			var optionsMap map[interface{}]interface{}
-
-			for k, val := range v {
-				if nameStr, ok := k.(string); ok {
-					name = nameStr
-					if optMap, ok := val.(map[interface{}]interface{}); ok {
-						optionsMap = optMap
-					} else {
-						return nil, fmt.Errorf("invalid group options at index %d: must be an object", i)
-					}
-				} else {
-					return nil, fmt.Errorf("invalid group name at index %d: must be a string", i)
-				}
-			}
+		parsedGroups = append(parsedGroups, group)
+		indexes = append(indexes, i)
+	}
 
-			// Parse options
-			options := &GroupOptions{}
+	if len(diags) == 0 {
+		indexes = nil
+	}
+	return parsedGroups, indexes, diags
+}
 
-			if fileRegex, ok := optionsMap["fileRegex"]; ok {
-				if fileRegexStr, ok := fileRegex.(string); ok {
-					options.FileRegex = &fileRegexStr
-				} else {
-					return nil, fmt.Errorf("invalid fileRegex at index %d: must be a string", i)
-				}
-			}
+// parseGroupEntry parses a single raw group entry at index i
+// Entries decoded from YAML, TOML or JSON are normalized first, so every format's slice
+// and map types are accepted
+func parseGroupEntry(i int, entry GroupEntry) (ParsedGroupEntry, error) {
+	switch v := normalizeDecoded(entry).(type) {
+	case string:
+		// Simple string group
+		return ParsedGroupEntry{
+			Name:    v,
+			Options: nil,
+		}, nil
+	case []interface{}:
+		// Array format [string, options]
+		if len(v) != 2 {
+			return ParsedGroupEntry{}, fmt.Errorf("invalid group entry at index %d: array must have exactly 2 elements", i)
+		}
 
-			if description, ok := optionsMap["description"]; ok {
-				if descriptionStr, ok := description.(string); ok {
-					options.Description = &descriptionStr
-				} else {
-					return nil, fmt.Errorf("invalid description at index %d: must be a string", i)
-				}
-			}
+		name, ok := v[0].(string)
+		if !ok {
+			return ParsedGroupEntry{}, fmt.Errorf("invalid group entry at index %d: first element must be a string", i)
+		}
 
-			parsedGroups = append(parsedGroups, ParsedGroupEntry{
-				Name:    name,
-				Options: options,
-			})
+		var options *GroupOptions
+		var err error
+
+		switch optMap := normalizeDecoded(v[1]).(type) {
 		case map[string]interface{}:
-			// Handle JSON object format with key as group name and value as options
-			// This format looks like: { "groupName": { options } }
-			if len(v) != 1 {
-				return nil, fmt.Errorf("invalid group entry at index %d: map must have exactly 1 key", i)
-			}
+			options, err = parseGroupOptions(i, optMap)
+		case string:
+			// Handle case where the second element is a string representation of a map
+			// This happens sometimes with YAML parsing of complex structures
+			options, err = parseGroupOptionsString(i, optMap)
+		default:
+			err = fmt.Errorf("invalid options format at index %d: %T", i, v[1])
+		}
+		if err != nil {
+			return ParsedGroupEntry{}, err
+		}
 
-			// Extract the single key (group name) and value (options)
-			var name string
-			var optionsMap map[string]interface{}
-
-			for k, val := range v {
-				name = k
-				if optMap, ok := val.(map[string]interface{}); ok {
-					optionsMap = optMap
-				} else {
-					return nil, fmt.Errorf("invalid group options at index %d: must be an object", i)
-				}
-			}
+		return ParsedGroupEntry{
+			Name:    name,
+			Options: options,
+		}, nil
+	case map[string]interface{}:
+		// Handle object format with key as group name and value as options
+		// This format looks like: - groupName: { options }
+		if len(v) != 1 {
+			return ParsedGroupEntry{}, fmt.Errorf("invalid group entry at index %d: map must have exactly 1 key", i)
+		}
 
-			// Parse options
-			options := &GroupOptions{}
+		for name, val := range v {
+			return parseGroupMapEntry(i, name, val)
+		}
+	}
 
-			if fileRegex, ok := optionsMap["fileRegex"]; ok {
-				if fileRegexStr, ok := fileRegex.(string); ok {
-					options.FileRegex = &fileRegexStr
-				} else {
-					return nil, fmt.Errorf("invalid fileRegex at index %d: must be a string", i)
-				}
-			}
+	return ParsedGroupEntry{}, fmt.Errorf("invalid group entry at index %d: must be a string, an array, or a map, got %T", i, entry)
+}
 
-			if description, ok := optionsMap["description"]; ok {
-				if descriptionStr, ok := description.(string); ok {
-					options.Description = &descriptionStr
-				} else {
-					return nil, fmt.Errorf("invalid description at index %d: must be a string", i)
-				}
-			}
+// parseGroupMapEntry parses the value of a single-key group map
+func parseGroupMapEntry(i int, name string, val interface{}) (ParsedGroupEntry, error) {
+	optionsMap, ok := normalizeDecoded(val).(map[string]interface{})
+	if !ok {
+		return ParsedGroupEntry{}, fmt.Errorf("invalid group options at index %d: must be an object", i)
+	}
 
-			parsedGroups = append(parsedGroups, ParsedGroupEntry{
-				Name:    name,
-				Options: options,
-			})
-		default:
-			return nil, fmt.Errorf("invalid group entry at index %d: must be a string, an array, or a map, got %T", i, entry)
+	options, err := parseGroupOptions(i, optionsMap)
+	if err != nil {
+		return ParsedGroupEntry{}, err
+	}
+
+	return ParsedGroupEntry{
+		Name:    name,
+		Options: options,
+	}, nil
+}
+
+// parseGroupOptions extracts GroupOptions from a decoded options object
+func parseGroupOptions(i int, optionsMap map[string]interface{}) (*GroupOptions, error) {
+	options := &GroupOptions{}
+
+	if fileRegex, ok := optionsMap["fileRegex"]; ok {
+		if fileRegexStr, ok := fileRegex.(string); ok {
+			options.FileRegex = &fileRegexStr
+		} else {
+			return nil, fmt.Errorf("invalid fileRegex at index %d: must be a string", i)
 		}
 	}
 
-	return parsedGroups, nil
+	if description, ok := optionsMap["description"]; ok {
+		if descriptionStr, ok := description.(string); ok {
+			options.Description = &descriptionStr
+		} else {
+			return nil, fmt.Errorf("invalid description at index %d: must be a string", i)
+		}
+	}
+
+	return options, nil
+}
+
+// parseGroupOptionsString parses options given as a string representation of a map
+func parseGroupOptionsString(i int, optStr string) (*GroupOptions, error) {
+	if optStr == "" {
+		// Empty options
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(optStr, "map[") {
+		return nil, fmt.Errorf("invalid options format at index %d: %s", i, optStr)
+	}
+
+	// Try to parse map[key:value] format
+	options := &GroupOptions{}
+
+	// Remove the map[] wrapper
+	optStr = strings.TrimPrefix(optStr, "map[")
+	optStr = strings.TrimSuffix(optStr, "]")
+
+	// Split by space to get key-value pairs
+	parts := strings.Split(optStr, " ")
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+
+		keyValue := strings.SplitN(part, ":", 2)
+		if len(keyValue) != 2 {
+			continue
+		}
+
+		key := keyValue[0]
+		value := keyValue[1]
+
+		switch key {
+		case "fileRegex":
+			options.FileRegex = &value
+		case "description":
+			options.Description = &value
+		}
+	}
+
+	return options, nil
+}
+
+// normalizeDecoded converts slices and maps produced by the YAML, TOML and JSON decoders,
+// such as map[interface{}]interface{} or []map[string]interface{}, to []interface{} and
+// map[string]interface{}
+// Map entries with non-string keys are dropped. Nested values are left as they are.
+func normalizeDecoded(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if _, ok := v.([]interface{}); ok {
+			return v
+		}
+		result := make([]interface{}, rv.Len())
+		for i := range result {
+			result[i] = rv.Index(i).Interface()
+		}
+		return result
+	case reflect.Map:
+		if _, ok := v.(map[string]interface{}); ok {
+			return v
+		}
+		result := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := iter.Key()
+			if key.Kind() == reflect.Interface {
+				key = key.Elem()
+			}
+			if key.Kind() == reflect.String {
+				result[key.String()] = iter.Value().Interface()
+			}
+		}
+		return result
+	}
+	return v
 }
@@ -0,0 +1,328 @@
+package mode
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+// wantGroups is the result every group shape in the tests below must parse to
+var wantGroups = []ParsedGroupEntry{
+	{Name: "read"},
+	{Name: "edit", Options: &GroupOptions{FileRegex: strPtr(`\.md$`), Description: strPtr("Markdown")}},
+	{Name: "command", Options: &GroupOptions{FileRegex: strPtr(`\.sh$`)}},
+}
+
+func TestParseModeFileGroupShapes(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name: "yaml map entries",
+			content: `---
+name: Test
+roleDefinition: You are Roo
+groups:
+  - read
+  - edit:
+      fileRegex: \.md$
+      description: Markdown
+  - command:
+      fileRegex: \.sh$
+---
+`,
+		},
+		{
+			name: "yaml tuple entries",
+			content: `---
+name: Test
+roleDefinition: You are Roo
+groups:
+  - read
+  - [edit, {fileRegex: \.md$, description: Markdown}]
+  - - command
+    - fileRegex: \.sh$
+---
+`,
+		},
+		{
+			name: "yaml with format suffix",
+			content: `---yaml
+name: Test
+roleDefinition: You are Roo
+groups: [read, {edit: {fileRegex: \.md$, description: Markdown}}, [command, {fileRegex: \.sh$}]]
+---
+`,
+		},
+		{
+			name: "toml inline table entries",
+			content: `+++
+name = "Test"
+roleDefinition = "You are Roo"
+groups = [
+  "read",
+  {edit = {fileRegex = '\.md$', description = "Markdown"}},
+  {command = {fileRegex = '\.sh$'}},
+]
++++
+`,
+		},
+		{
+			name: "toml tuple entries",
+			content: `---toml
+name = "Test"
+roleDefinition = "You are Roo"
+groups = ["read", ["edit", {fileRegex = '\.md$', description = "Markdown"}], ["command", {fileRegex = '\.sh$'}]]
+---
+`,
+		},
+		{
+			name: "json object entries",
+			content: `;;;
+{
+  "name": "Test",
+  "roleDefinition": "You are Roo",
+  "groups": ["read", {"edit": {"fileRegex": "\\.md$", "description": "Markdown"}}, {"command": {"fileRegex": "\\.sh$"}}]
+}
+;;;
+`,
+		},
+		{
+			name: "json tuple entries",
+			content: `---json
+{
+  "name": "Test",
+  "roleDefinition": "You are Roo",
+  "groups": ["read", ["edit", {"fileRegex": "\\.md$", "description": "Markdown"}], ["command", {"fileRegex": "\\.sh$"}]]
+}
+---
+`,
+		},
+		{
+			name: "json without delimiters",
+			content: `{
+  "name": "Test",
+  "roleDefinition": "You are Roo",
+  "groups": ["read", {"edit": {"fileRegex": "\\.md$", "description": "Markdown"}}, ["command", {"fileRegex": "\\.sh$"}]]
+}
+`,
+		},
+		{
+			name: "json without delimiters with nested objects",
+			content: `{
+  "name": "Test",
+  "roleDefinition": "You are Roo",
+  "groups": [
+    "read",
+    {
+      "edit": {
+        "fileRegex": "\\.md$",
+        "description": "Markdown"
+      }
+    },
+    ["command", {
+      "fileRegex": "\\.sh$"
+    }]
+  ]
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.md")
+			if err := os.WriteFile(path, []byte(tt.content+"\nInstructions\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := ParseModeFile(path)
+			if err != nil {
+				t.Fatalf("ParseModeFile() error = %v", err)
+			}
+			if !reflect.DeepEqual(config.GroupsParsed, wantGroups) {
+				t.Errorf("GroupsParsed = %s, want %s", formatGroups(config.GroupsParsed), formatGroups(wantGroups))
+			}
+			if config.Name != "Test" || config.RoleDefinition != "You are Roo" {
+				t.Errorf("Name, RoleDefinition = %q, %q, want %q, %q", config.Name, config.RoleDefinition, "Test", "You are Roo")
+			}
+			if config.CustomInstructions == nil || *config.CustomInstructions != "Instructions" {
+				t.Errorf("CustomInstructions = %v, want %q", config.CustomInstructions, "Instructions")
+			}
+			if err := ValidateMode(config); err != nil {
+				t.Errorf("ValidateMode() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestParseGroupEntriesDecodedTypes(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups []GroupEntry
+	}{
+		{
+			name: "interface keys",
+			groups: []GroupEntry{
+				"read",
+				map[interface{}]interface{}{"edit": map[interface{}]interface{}{"fileRegex": `\.md$`, "description": "Markdown"}},
+				[]interface{}{"command", map[interface{}]interface{}{"fileRegex": `\.sh$`}},
+			},
+		},
+		{
+			name: "typed maps and slices",
+			groups: []GroupEntry{
+				"read",
+				map[string]map[string]string{"edit": {"fileRegex": `\.md$`, "description": "Markdown"}},
+				[]interface{}{"command", map[string]string{"fileRegex": `\.sh$`}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseGroupEntries(tt.groups)
+			if err != nil {
+				t.Fatalf("ParseGroupEntries() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, wantGroups) {
+				t.Errorf("ParseGroupEntries() = %s, want %s", formatGroups(got), formatGroups(wantGroups))
+			}
+		})
+	}
+}
+
+func TestParseGroupEntriesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		groups  []GroupEntry
+		wantErr string
+	}{
+		{
+			name:    "tuple with one element",
+			groups:  []GroupEntry{[]interface{}{"edit"}},
+			wantErr: "invalid group entry at index 0: array must have exactly 2 elements",
+		},
+		{
+			name:    "map with two keys",
+			groups:  []GroupEntry{map[string]interface{}{"edit": map[string]interface{}{}, "read": map[string]interface{}{}}},
+			wantErr: "invalid group entry at index 0: map must have exactly 1 key",
+		},
+		{
+			name:    "non-string fileRegex",
+			groups:  []GroupEntry{map[string]interface{}{"edit": map[string]interface{}{"fileRegex": 1}}},
+			wantErr: "invalid fileRegex at index 0: must be a string",
+		},
+		{
+			name:    "duplicate group",
+			groups:  []GroupEntry{"read", "read"},
+			wantErr: `duplicate group "read" at index 1 (already listed at index 0)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseGroupEntries(tt.groups)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ParseGroupEntries() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateModeBlankOptionalFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name: "yaml blank whenToUse",
+			content: `---
+name: Test
+roleDefinition: You are Roo
+groups: [read]
+whenToUse: " "
+---
+`,
+			wantErr: "whenToUse must not be empty when present",
+		},
+		{
+			name: "toml blank whenToUse",
+			content: `+++
+name = "Test"
+roleDefinition = "You are Roo"
+groups = ["read"]
+whenToUse = ""
++++
+`,
+			wantErr: "whenToUse must not be empty when present",
+		},
+		{
+			name: "json blank description",
+			content: `;;;
+{"name": "Test", "roleDefinition": "You are Roo", "groups": ["read"], "description": "  "}
+;;;
+`,
+			wantErr: "description must not be empty when present",
+		},
+		{
+			name: "toml without optional fields",
+			content: `+++
+name = "Test"
+roleDefinition = "You are Roo"
+groups = ["read"]
++++
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.md")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := ParseModeFile(path)
+			if err != nil {
+				t.Fatalf("ParseModeFile() error = %v", err)
+			}
+			err = ValidateMode(config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateMode() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateMode() error = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// formatGroups formats parsed groups with their options for test failure messages
+func formatGroups(groups []ParsedGroupEntry) string {
+	s := ""
+	for _, g := range groups {
+		s += g.Name
+		if g.Options != nil {
+			if g.Options.FileRegex != nil {
+				s += " fileRegex=" + *g.Options.FileRegex
+			}
+			if g.Options.Description != nil {
+				s += " description=" + *g.Options.Description
+			}
+		}
+		s += "; "
+	}
+	return s
+}
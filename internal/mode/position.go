@@ -0,0 +1,34 @@
+package mode
+
+import (
+	"strings"
+)
+
+// Position is a 1-based line and column in a mode file
+type Position struct {
+	Line   int
+	Column int
+}
+
+// Position returns the source position of a frontmatter field such as "groups[3].fileRegex"
+// Falls back to the closest enclosing field, then to the start of the frontmatter
+func (c *Config) Position(field string) Position {
+	return lookupPosition(c.Positions, field)
+}
+
+// lookupPosition finds the position of field or its closest known parent
+func lookupPosition(positions map[string]Position, field string) Position {
+	for {
+		if pos, ok := positions[field]; ok {
+			return pos
+		}
+		if field == "" {
+			return Position{}
+		}
+		if i := strings.LastIndexAny(field, ".["); i >= 0 {
+			field = field[:i]
+		} else {
+			field = ""
+		}
+	}
+}
@@ -0,0 +1,391 @@
+package mode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sourcePosition is the position of a value in TOML or JSON frontmatter, and of its key
+// when it is an object member
+type sourcePosition struct {
+	key   Position
+	value Position
+}
+
+// Paths of values in TOML and JSON frontmatter join object keys with "." and add "[i]"
+// for array elements, e.g. "groups[1][1].fileRegex"
+
+// childPath returns the path of the member key of the object at path
+func childPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// elementPath returns the path of element i of the array at path
+func elementPath(path string, i int) string {
+	return fmt.Sprintf("%s[%d]", path, i)
+}
+
+// setNodePositions sets the lines and columns of a node tree built from decoded TOML or JSON
+// from the positions found in its source. Nodes without a known position get their parent's.
+func setNodePositions(node *yaml.Node, path string, positions map[string]sourcePosition, parent Position) {
+	if node.Kind == yaml.DocumentNode {
+		for _, child := range node.Content {
+			setNodePositions(child, path, positions, parent)
+		}
+		return
+	}
+
+	pos := parent
+	if p, ok := positions[path]; ok {
+		pos = p.value
+	}
+	node.Line, node.Column = pos.Line, pos.Column
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			child := childPath(path, key.Value)
+			key.Line, key.Column = pos.Line, pos.Column
+			if p, ok := positions[child]; ok {
+				key.Line, key.Column = p.key.Line, p.key.Column
+			}
+			setNodePositions(value, child, positions, pos)
+		}
+	case yaml.SequenceNode:
+		for i, element := range node.Content {
+			setNodePositions(element, elementPath(path, i), positions, pos)
+		}
+	}
+}
+
+// sourceLines converts byte offsets of a source to 1-based lines and byte columns
+type sourceLines []int
+
+// newSourceLines returns the offsets of the lines of data
+func newSourceLines(data []byte) sourceLines {
+	lines := sourceLines{0}
+	for i, b := range data {
+		if b == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	return lines
+}
+
+// position returns the line and column of offset
+func (lines sourceLines) position(offset int) Position {
+	line := len(lines) - 1
+	for line > 0 && lines[line] > offset {
+		line--
+	}
+	return Position{Line: line + 1, Column: offset - lines[line] + 1}
+}
+
+// jsonPositions returns the position of each value and member key of JSON data that
+// json.Unmarshal accepts
+func jsonPositions(data []byte) map[string]sourcePosition {
+	s := &jsonScanner{
+		data:      data,
+		lines:     newSourceLines(data),
+		decoder:   json.NewDecoder(bytes.NewReader(data)),
+		positions: map[string]sourcePosition{},
+	}
+	s.value("", Position{})
+	return s.positions
+}
+
+// jsonScanner records positions while reading the tokens of a JSON value
+type jsonScanner struct {
+	data      []byte
+	lines     sourceLines
+	decoder   *json.Decoder
+	positions map[string]sourcePosition
+}
+
+// next returns the next token and the position it starts at
+func (s *jsonScanner) next() (json.Token, Position, error) {
+	offset := int(s.decoder.InputOffset())
+	for offset < len(s.data) && bytes.IndexByte([]byte(" \t\r\n,:"), s.data[offset]) >= 0 {
+		offset++
+	}
+	token, err := s.decoder.Token()
+	return token, s.lines.position(offset), err
+}
+
+// value records the positions of the value at path and its members
+func (s *jsonScanner) value(path string, key Position) error {
+	token, pos, err := s.next()
+	if err != nil {
+		return err
+	}
+	s.positions[path] = sourcePosition{key: key, value: pos}
+
+	switch token {
+	case json.Delim('{'):
+		for s.decoder.More() {
+			name, keyPos, err := s.next()
+			if err != nil {
+				return err
+			}
+			if err := s.value(childPath(path, fmt.Sprint(name)), keyPos); err != nil {
+				return err
+			}
+		}
+	case json.Delim('['):
+		for i := 0; s.decoder.More(); i++ {
+			if err := s.value(elementPath(path, i), Position{}); err != nil {
+				return err
+			}
+		}
+	default:
+		return nil
+	}
+
+	// Closing delimiter
+	if _, _, err := s.next(); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// tomlPositions returns the position of each value and key of TOML data that toml.Decode
+// accepts
+// Key paths of tables and arrays of tables are resolved like the decoder does. Dotted keys
+// and table headers record a position for every table they define.
+func tomlPositions(data []byte) map[string]sourcePosition {
+	s := &tomlScanner{
+		data:      data,
+		lines:     newSourceLines(data),
+		positions: map[string]sourcePosition{},
+	}
+	s.document()
+	return s.positions
+}
+
+// tomlScanner records positions while reading TOML
+// The data has already been decoded successfully, so syntax errors aren't reported
+type tomlScanner struct {
+	data      []byte
+	offset    int
+	lines     sourceLines
+	positions map[string]sourcePosition
+}
+
+// peek returns the byte at the offset, or 0 at the end
+func (s *tomlScanner) peek() byte {
+	if s.offset < len(s.data) {
+		return s.data[s.offset]
+	}
+	return 0
+}
+
+// record stores the position of the key and value at path unless it is already known
+func (s *tomlScanner) record(path string, key, value Position) {
+	if _, ok := s.positions[path]; !ok {
+		s.positions[path] = sourcePosition{key: key, value: value}
+	}
+}
+
+// skipSpace skips spaces and tabs, and newlines and comments if multiline is set
+func (s *tomlScanner) skipSpace(multiline bool) {
+	for s.offset < len(s.data) {
+		switch c := s.data[s.offset]; {
+		case c == ' ' || c == '\t':
+			s.offset++
+		case multiline && (c == '\r' || c == '\n'):
+			s.offset++
+		case multiline && c == '#':
+			s.skipLine()
+		default:
+			return
+		}
+	}
+}
+
+// skipLine skips to the start of the next line
+func (s *tomlScanner) skipLine() {
+	if s.offset >= len(s.data) {
+		s.offset = len(s.data)
+		return
+	}
+	if i := bytes.IndexByte(s.data[s.offset:], '\n'); i >= 0 {
+		s.offset += i + 1
+	} else {
+		s.offset = len(s.data)
+	}
+}
+
+// document reads key/value pairs, table headers and array of tables headers
+func (s *tomlScanner) document() {
+	table := ""
+	arrays := map[string]int{} // Number of elements of each array of tables
+	for {
+		s.skipSpace(true)
+		if s.offset >= len(s.data) {
+			return
+		}
+
+		if s.peek() != '[' {
+			s.keyValue(table)
+			s.skipLine()
+			continue
+		}
+
+		// Table headers start from the root
+		start := s.lines.position(s.offset)
+		s.offset++
+		isArray := s.peek() == '['
+		if isArray {
+			s.offset++
+		}
+		table = s.key("", start)
+		if isArray {
+			i := arrays[table]
+			arrays[table]++
+			table = elementPath(table, i)
+			s.positions[table] = sourcePosition{key: start, value: start}
+		}
+		s.skipLine()
+	}
+}
+
+// keyValue reads a key/value pair in the table at path
+func (s *tomlScanner) keyValue(path string) {
+	path = s.key(path, Position{})
+	s.skipSpace(false)
+	if s.peek() == '=' {
+		s.offset++
+	}
+	s.value(path)
+}
+
+// key reads a possibly dotted key in the table at path and returns its path
+// Each table the key defines gets the position of its segment, or value if it is set
+func (s *tomlScanner) key(path string, value Position) string {
+	for {
+		s.skipSpace(false)
+		start := s.offset
+		pos := s.lines.position(start)
+		var name string
+		switch s.peek() {
+		case '"':
+			s.string()
+			if unquoted, err := strconv.Unquote(string(s.data[start:s.offset])); err == nil {
+				name = unquoted
+			}
+		case '\'':
+			s.string()
+			name = string(bytes.Trim(s.data[start:s.offset], "'"))
+		default:
+			for s.offset < len(s.data) && isBareKeyByte(s.data[s.offset]) {
+				s.offset++
+			}
+			name = string(s.data[start:s.offset])
+		}
+
+		path = childPath(path, name)
+		if value == (Position{}) {
+			s.record(path, pos, pos)
+		} else {
+			s.record(path, pos, value)
+		}
+
+		s.skipSpace(false)
+		if s.peek() != '.' {
+			return path
+		}
+		s.offset++
+	}
+}
+
+// isBareKeyByte reports whether c may appear in a bare TOML key
+func isBareKeyByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// value reads the value at path
+func (s *tomlScanner) value(path string) {
+	s.skipSpace(false)
+	pos := s.lines.position(s.offset)
+	if p, ok := s.positions[path]; ok {
+		s.positions[path] = sourcePosition{key: p.key, value: pos}
+	} else {
+		s.positions[path] = sourcePosition{key: pos, value: pos}
+	}
+
+	switch s.peek() {
+	case '"', '\'':
+		s.string()
+	case '[':
+		s.offset++
+		for i := 0; ; i++ {
+			s.skipSpace(true)
+			if c := s.peek(); c == ']' || c == 0 {
+				s.offset++
+				return
+			}
+			start := s.offset
+			s.value(elementPath(path, i))
+			s.skipSpace(true)
+			if s.peek() == ',' || s.offset == start {
+				s.offset++
+			}
+		}
+	case '{':
+		s.offset++
+		for {
+			s.skipSpace(true)
+			if c := s.peek(); c == '}' || c == 0 {
+				s.offset++
+				return
+			}
+			start := s.offset
+			s.keyValue(path)
+			s.skipSpace(true)
+			if s.peek() == ',' || s.offset == start {
+				s.offset++
+			}
+		}
+	default:
+		// Numbers, booleans and dates end at a separator
+		for s.offset < len(s.data) && bytes.IndexByte([]byte(",]}#\r\n"), s.data[s.offset]) < 0 {
+			s.offset++
+		}
+	}
+}
+
+// string skips a basic, literal or multi-line string
+func (s *tomlScanner) string() {
+	quote := s.data[s.offset]
+	delim := []byte{quote}
+	if bytes.HasPrefix(s.data[s.offset:], []byte{quote, quote, quote}) {
+		delim = []byte{quote, quote, quote}
+	}
+	s.offset += len(delim)
+
+	for s.offset < len(s.data) {
+		switch {
+		case quote == '"' && s.data[s.offset] == '\\':
+			s.offset += 2
+		case bytes.HasPrefix(s.data[s.offset:], delim):
+			s.offset += len(delim)
+			// Up to two more quotes may end a multi-line string
+			for len(delim) == 3 && s.peek() == quote {
+				s.offset++
+			}
+			return
+		default:
+			s.offset++
+		}
+	}
+	s.offset = min(s.offset, len(s.data))
+}
@@ -0,0 +1,132 @@
+package mode
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFrontmatterPositions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name: "toml",
+			content: `+++
+name = "Test"
+roleDefiniton = """
+You are Roo
+"""
+# Tool groups
+groups = [
+  "read",
+  "browse",
+  ["edit", { fileRegex = "(", descripton = "Broken" }],
+]
++++
+`,
+			want: []string{
+				"10:31: unknown group option \"descripton\" at index 2 (did you mean \"description\"?)",
+				"3:1: unknown frontmatter key \"roleDefiniton\" (did you mean \"roleDefinition\"?)",
+				"9:3: unknown group \"browse\" at index 1 (did you mean \"browser\"?)",
+				"10:26: invalid fileRegex at index 2: position 1: unterminated group",
+				"1:1: role definition (markdown content) is required",
+			},
+		},
+		{
+			name: "toml tables",
+			content: `---toml
+name = "Test"
+roleDefinition = "You are Roo"
+groups = ["read"]
+source = "team"
+
+[vars]
+'lang' = "Go"
+"quoted.key" = 1
+nested.key = true
+---
+`,
+			want: []string{
+				"5:10: invalid source \"team\": must be one of global, project",
+			},
+		},
+		{
+			name: "toml array of tables",
+			content: `+++
+name = "Test"
+roleDefinition = "You are Roo"
+
+[[groups]]
+read = {}
+
+[[groups]]
+edit = { fileRegex = "(" }
++++
+`,
+			want: []string{
+				"9:22: invalid fileRegex at index 1: position 1: unterminated group",
+			},
+		},
+		{
+			name: "json",
+			content: `;;;
+{
+  "name": "Test",
+  "roleDefinition": "You are Roo",
+  "groups": ["read", {"edit": {"fileRegex": "(", "descripton": "Broken"}}, "browse"],
+  "colour": "red"
+}
+;;;
+`,
+			want: []string{
+				"6:3: unknown frontmatter key \"colour\"",
+				"5:50: unknown group option \"descripton\" at index 1 (did you mean \"description\"?)",
+				"5:45: invalid fileRegex at index 1: position 1: unterminated group",
+				"5:76: unknown group \"browse\" at index 2 (did you mean \"browser\"?)",
+			},
+		},
+		{
+			name: "json without delimiters",
+			content: `
+{
+  "name": "",
+  "roleDefinition": "You are Roo",
+  "groups": ["read"]
+}
+`,
+			want: []string{"3:11: name is required"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.md")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var diags Diagnostics
+			config, err := ParseModeFile(path)
+			if err != nil {
+				diags = AsDiagnostics(path, err)
+			}
+			if config == nil {
+				t.Fatalf("ParseModeFile() error = %v", err)
+			}
+			diags = append(diags, validateMode(config)...)
+
+			var got []string
+			for _, d := range diags {
+				got = append(got, fmt.Sprintf("%d:%d: %s", d.Line, d.Column, d.Message))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diagnostics = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package mode
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// metadataKeys lists the frontmatter keys known to roomode
+	metadataKeys = yamlKeys(reflect.TypeOf(Metadata{}))
+
+	// keyHints explains keys that look valid but don't belong in frontmatter
+	keyHints = map[string]string{
+		"slug":               "the slug is taken from the file name",
+		"customInstructions": "custom instructions are taken from the markdown body",
+	}
+
+	// groupOptionKeys lists the keys allowed in group options
+	groupOptionKeys = yamlKeys(reflect.TypeOf(GroupOptions{}))
+)
+
+// KnownKeys returns the frontmatter keys that are accepted in strict mode
+func KnownKeys() []string {
+	return append([]string(nil), metadataKeys...)
+}
+
+// yamlKeys returns the YAML keys of a struct type's fields
+func yamlKeys(t reflect.Type) []string {
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name != "" && name != "-" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// unknownKeys reports frontmatter keys and group option keys that roomode doesn't know
+// lineOffset is added to node lines to convert them to file lines
+func unknownKeys(root *yaml.Node, lineOffset int) Diagnostics {
+	var diags Diagnostics
+	report := func(key *yaml.Node, field, format string, args ...interface{}) {
+		diags = append(diags, Diagnostic{
+			Line:    key.Line + lineOffset,
+			Column:  key.Column,
+			Field:   field,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	doc := root
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		doc = doc.Content[0]
+	}
+	if doc.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key, value := doc.Content[i], doc.Content[i+1]
+		if hint, ok := keyHints[key.Value]; ok {
+			report(key, key.Value, "unknown frontmatter key %q: %s", key.Value, hint)
+			continue
+		}
+		if !slices.Contains(metadataKeys, key.Value) {
+			report(key, key.Value, "unknown frontmatter key %q%s", key.Value, didYouMean(key.Value, metadataKeys))
+			continue
+		}
+
+		if key.Value != "groups" || value.Kind != yaml.SequenceNode {
+			continue
+		}
+
+		for j, entry := range value.Content {
+			if (entry.Kind != yaml.SequenceNode && entry.Kind != yaml.MappingNode) || len(entry.Content) != 2 {
+				continue
+			}
+			options := entry.Content[1]
+			if options.Kind != yaml.MappingNode {
+				continue
+			}
+			for k := 0; k+1 < len(options.Content); k += 2 {
+				optionKey := options.Content[k]
+				if !slices.Contains(groupOptionKeys, optionKey.Value) {
+					field := fmt.Sprintf("groups[%d].%s", j, optionKey.Value)
+					report(optionKey, field, "unknown group option %q at index %d%s", optionKey.Value, j, didYouMean(optionKey.Value, groupOptionKeys))
+				}
+			}
+		}
+	}
+
+	return diags
+}
@@ -0,0 +1,102 @@
+package mode
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// TemplateData is the data available to templates in a mode
+type TemplateData struct {
+	Project ProjectInfo
+	Vars    map[string]interface{} // Values from the vars frontmatter block
+	Mode    TemplateMode
+}
+
+// ProjectInfo describes the project a mode is exported from
+type ProjectInfo struct {
+	Name string // Name of the project directory
+	Root string // Absolute path of the project directory
+}
+
+// TemplateMode describes the mode being rendered
+type TemplateMode struct {
+	Slug string
+	Name string
+}
+
+// IsTemplate reports whether the role definition and instructions of the mode are templates
+// Templating is enabled with "template: true" or by declaring vars, so that modes containing
+// literal "{{" are left alone
+func (c *Config) IsTemplate() bool {
+	return c.Template || len(c.Vars) > 0
+}
+
+// RenderTemplates returns a copy of the mode with its role definition and custom instructions
+// rendered as Go text/template templates
+// Referencing an undefined variable or an unset environment variable is an error.
+// Modes that aren't templates are returned as they are.
+func RenderTemplates(config *Config, project ProjectInfo) (*Config, error) {
+	if !config.IsTemplate() {
+		return config, nil
+	}
+
+	data := TemplateData{
+		Project: project,
+		Vars:    config.Vars,
+		Mode:    TemplateMode{Slug: config.Slug, Name: config.Name},
+	}
+	if data.Vars == nil {
+		data.Vars = map[string]interface{}{}
+	}
+
+	rendered := *config
+
+	roleDefinition, err := renderTemplate("roleDefinition", config.RoleDefinition, data)
+	if err != nil {
+		return nil, err
+	}
+	rendered.RoleDefinition = roleDefinition
+
+	if config.CustomInstructions != nil {
+		instructions, err := renderTemplate("instructions", *config.CustomInstructions, data)
+		if err != nil {
+			return nil, err
+		}
+		rendered.CustomInstructions = &instructions
+	}
+
+	return &rendered, nil
+}
+
+// parseTemplate parses text as a mode template
+func parseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"env": templateEnv}).
+		Parse(text)
+}
+
+// renderTemplate renders text as a mode template
+func renderTemplate(name, text string, data TemplateData) (string, error) {
+	tmpl, err := parseTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// templateEnv returns the value of an environment variable, failing if it isn't set
+func templateEnv(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
@@ -3,30 +3,127 @@ package mode
 import (
 	"fmt"
 	"regexp"
+	"strings"
 )
 
+// The rules below mirror RooCode's modeConfigSchema, so that any mode roomode
+// exports is also accepted by RooCode
+
+// SlugPattern is the regular expression slugs must match: only letters, numbers and dashes
+// The JSON Schemas of roomode use it too, so it must be valid in Go and JavaScript
+const SlugPattern = `^[a-zA-Z0-9-]+$`
+
+var (
+	validSlugRegex = regexp.MustCompile(SlugPattern)
+
+	// validSources lists the allowed values of the source field
+	validSources = []string{"global", "project"}
+)
+
+// IsValidSlug checks if a slug is accepted by RooCode
+func IsValidSlug(slug string) bool {
+	return validSlugRegex.MatchString(slug)
+}
+
 // ValidateMode validates the contents of a Config
+// All problems are collected and returned together as Diagnostics
 func ValidateMode(mode *Config) error {
+	return validateMode(mode).errorOrNil()
+}
+
+// validateMode returns every problem found in a Config
+func validateMode(mode *Config) Diagnostics {
+	var diags Diagnostics
+	report := func(field, format string, args ...interface{}) {
+		pos := mode.Position(field)
+		diags = append(diags, Diagnostic{
+			File:    mode.FilePath,
+			Line:    pos.Line,
+			Column:  pos.Column,
+			Field:   field,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if mode.Slug == "" {
+		report("slug", "slug is required")
+	} else if !IsValidSlug(mode.Slug) {
+		report("slug", "invalid slug %q: must contain only letters, numbers and dashes", mode.Slug)
+	}
 
 	if mode.Name == "" {
-		return fmt.Errorf("name is required")
+		report("name", "name is required")
 	}
 
-	if len(mode.GroupsParsed) == 0 {
-		return fmt.Errorf("at least one group is required")
+	// Groups that couldn't be parsed are already reported
+	if len(mode.GroupsParsed) == 0 && mode.groupIndexes == nil {
+		report("groups", "at least one group is required")
 	}
 
-	for i, group := range mode.GroupsParsed {
+	for j, group := range mode.GroupsParsed {
+		i := mode.groupIndex(j)
+		if !IsToolGroup(group.Name) {
+			report(fmt.Sprintf("groups[%d]", i), "unknown group %q at index %d%s", group.Name, i, unknownGroupHint(group.Name))
+		}
+
 		if group.Options != nil && group.Options.FileRegex != nil {
-			if _, err := regexp.Compile(*group.Options.FileRegex); err != nil {
-				return fmt.Errorf("invalid fileRegex at index %d: %s", i, err.Error())
+			// RooCode evaluates fileRegex as a JavaScript RegExp
+			if err := ValidateJSRegex(*group.Options.FileRegex); err != nil {
+				report(fmt.Sprintf("groups[%d].fileRegex", i), "invalid fileRegex at index %d: %s", i, err.Error())
 			}
 		}
 	}
 
 	if mode.RoleDefinition == "" {
-		return fmt.Errorf("role definition (markdown content) is required")
+		report("roleDefinition", "role definition (markdown content) is required")
 	}
 
-	return nil
+	// Templates are rendered at export, but syntax errors can be reported right away
+	if mode.IsTemplate() {
+		if _, err := parseTemplate("roleDefinition", mode.RoleDefinition); err != nil {
+			report("roleDefinition", "invalid template: %s", err)
+		}
+		if mode.CustomInstructions != nil {
+			if _, err := parseTemplate("instructions", *mode.CustomInstructions); err != nil {
+				diags = append(diags, Diagnostic{
+					File:    mode.FilePath,
+					Line:    mode.BodyLine,
+					Column:  1,
+					Message: fmt.Sprintf("invalid template: %s", err),
+				})
+			}
+		}
+	}
+
+	// whenToUse and description are optional, but must not be blank when present
+	if mode.Keys["whenToUse"] && strings.TrimSpace(mode.WhenToUse) == "" {
+		report("whenToUse", "whenToUse must not be empty when present")
+	}
+	if mode.Keys["description"] && strings.TrimSpace(mode.Description) == "" {
+		report("description", "description must not be empty when present")
+	}
+
+	if mode.Source != "" && !isValidSource(mode.Source) {
+		report("source", "invalid source %q: must be one of %s", mode.Source, strings.Join(validSources, ", "))
+	}
+
+	return diags
+}
+
+// unknownGroupHint suggests a known tool group for name, or lists all of them
+func unknownGroupHint(name string) string {
+	if hint := didYouMean(name, ToolGroups); hint != "" {
+		return hint
+	}
+	return fmt.Sprintf(" (valid groups: %s)", strings.Join(ToolGroups, ", "))
+}
+
+// isValidSource reports whether source is an allowed value of the source field
+func isValidSource(source string) bool {
+	for _, s := range validSources {
+		if s == source {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,93 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/upamune/roomode/internal/mode"
+)
+
+// Output formats for diagnostics
+const (
+	FormatText   = "text"
+	FormatGitHub = "github"
+	FormatSARIF  = "sarif"
+)
+
+// validationRuleID is the rule ID used for validation errors, which have no lint rule
+const validationRuleID = "validation"
+
+// Rule describes a rule that can be referenced by diagnostics
+type Rule struct {
+	ID          string
+	Description string
+}
+
+// Options configures how diagnostics are written
+type Options struct {
+	ToolVersion string
+	Rules       []Rule // Rules referenced by diagnostics, listed in SARIF output
+}
+
+// Write writes diagnostics to w in the given format
+// File paths should be relative to the repository root
+func Write(w io.Writer, format string, diags mode.Diagnostics, opts Options) error {
+	switch format {
+	case FormatText, "":
+		return writeText(w, diags)
+	case FormatGitHub:
+		return writeGitHub(w, diags)
+	case FormatSARIF:
+		return writeSARIF(w, diags, opts)
+	default:
+		return fmt.Errorf("unknown output format: %s", format)
+	}
+}
+
+// writeText writes one file:line:col: message line per diagnostic
+func writeText(w io.Writer, diags mode.Diagnostics) error {
+	for _, d := range diags {
+		if _, err := fmt.Fprintln(w, d.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeGitHub writes GitHub Actions workflow commands that annotate pull requests
+// See https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions
+func writeGitHub(w io.Writer, diags mode.Diagnostics) error {
+	for _, d := range diags {
+		command := "error"
+		if !d.IsError() {
+			command = "warning"
+		}
+
+		props := []string{"file=" + escapeProperty(filepath.ToSlash(d.File))}
+		if d.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", d.Line), fmt.Sprintf("col=%d", d.Column))
+		}
+		title := d.Rule
+		if title == "" {
+			title = validationRuleID
+		}
+		props = append(props, "title="+escapeProperty("roomode "+title))
+
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", command, strings.Join(props, ","), escapeData(d.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeData escapes the message of a workflow command
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property value of a workflow command
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,111 @@
+package report
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/upamune/roomode/internal/mode"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// testDiagnostics covers validation errors, lint warnings, diagnostics without a position and
+// text that must be escaped
+var testDiagnostics = mode.Diagnostics{
+	{
+		File:    ".roo/modes/test.md",
+		Line:    3,
+		Column:  17,
+		Field:   "roleDefinition",
+		Message: "roleDefinition is required",
+	},
+	{
+		File:     ".roo/modes/test.md",
+		Line:     7,
+		Column:   5,
+		Field:    "groups[1]",
+		Message:  "edit group has no fileRegex, so the mode can edit any file",
+		Severity: mode.SeverityWarning,
+		Rule:     "edit-without-file-regex",
+	},
+	{
+		File:     ".roo/modes/a,b:c%.md",
+		Message:  "100% broken:\r\nsecond line",
+		Severity: mode.SeverityError,
+		Rule:     "role-definition-prefix",
+	},
+}
+
+var testOptions = Options{
+	ToolVersion: "v1.2.3",
+	Rules: []Rule{
+		{ID: "role-definition-prefix", Description: `roleDefinition must start with "You are Roo"`},
+		{ID: "edit-without-file-regex", Description: "The edit group should be restricted with a fileRegex"},
+	},
+}
+
+func TestWriteGolden(t *testing.T) {
+	tests := []struct {
+		format string
+		golden string
+	}{
+		{FormatText, "text.golden"},
+		{FormatGitHub, "github.golden"},
+		{FormatSARIF, "sarif.golden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, tt.format, testDiagnostics, testOptions); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+
+			path := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != string(want) {
+				t.Errorf("Write(%s) =\n%s\nwant\n%s", tt.format, got, want)
+			}
+		})
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, "xml", testDiagnostics, testOptions); err == nil {
+		t.Error("Write() error = nil, want an error for an unknown format")
+	}
+}
+
+func TestEscapeGitHub(t *testing.T) {
+	tests := []struct {
+		in       string
+		data     string
+		property string
+	}{
+		{"plain", "plain", "plain"},
+		{"50%", "50%25", "50%25"},
+		{"a\r\nb", "a%0D%0Ab", "a%0D%0Ab"},
+		{"a:b,c", "a:b,c", "a%3Ab%2Cc"},
+		{"%0A", "%250A", "%250A"},
+	}
+
+	for _, tt := range tests {
+		if got := escapeData(tt.in); got != tt.data {
+			t.Errorf("escapeData(%q) = %q, want %q", tt.in, got, tt.data)
+		}
+		if got := escapeProperty(tt.in); got != tt.property {
+			t.Errorf("escapeProperty(%q) = %q, want %q", tt.in, got, tt.property)
+		}
+	}
+}
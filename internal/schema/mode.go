@@ -0,0 +1,106 @@
+package schema
+
+import (
+	"reflect"
+	"slices"
+	"sort"
+
+	"github.com/upamune/roomode/internal/mode"
+)
+
+// Frontmatter returns the JSON Schema of mode file frontmatter (mode.Metadata)
+func Frontmatter() *Schema {
+	g := newModeGenerator("yaml", true)
+	s := g.Generate(reflect.TypeOf(mode.Metadata{}))
+	s.Schema = Draft
+	s.Title = "roomode mode file frontmatter"
+
+	// Unknown keys are only allowed when the file opts out with "strict: false"
+	keys := mode.KnownKeys()
+	sort.Strings(keys)
+	strict := &Schema{
+		If: &Schema{
+			Properties: map[string]*Schema{"strict": {Const: false}},
+			Required:   []string{"strict"},
+		},
+		Else: &Schema{PropertyNames: &Schema{Enum: keys}},
+	}
+
+	// groups and roleDefinition may be inherited from the mode named by extends
+	inherited := []string{"groups", "roleDefinition"}
+	required := s.Required[:0]
+	for _, name := range s.Required {
+		if !slices.Contains(inherited, name) {
+			required = append(required, name)
+		}
+	}
+	s.Required = required
+	extends := &Schema{
+		If:   &Schema{Required: []string{"extends"}},
+		Else: &Schema{Required: inherited},
+	}
+
+	s.AllOf = []*Schema{strict, extends}
+	return s
+}
+
+// Generate returns the JSON Schema of a type that contains mode groups, such as the .roomodes file
+// Groups use the formats RooCode accepts in .roomodes: a name or a [name, options] tuple
+func Generate(v interface{}, tagName, title string) *Schema {
+	g := newModeGenerator(tagName, false)
+	s := g.Generate(reflect.TypeOf(v))
+	s.Schema = Draft
+	s.Title = title
+	return s
+}
+
+// newModeGenerator returns a Generator that knows the schema of mode.GroupEntry, and the
+// slug pattern as patternRef=slug
+// allowMapEntries enables the {name: options} format supported in frontmatter
+func newModeGenerator(tagName string, allowMapEntries bool) *Generator {
+	g := &Generator{
+		TagName:  tagName,
+		Patterns: map[string]string{"slug": mode.SlugPattern},
+	}
+	g.Types = map[reflect.Type]func() *Schema{
+		reflect.TypeOf((*mode.GroupEntry)(nil)).Elem(): func() *Schema {
+			return groupEntrySchema(g, allowMapEntries)
+		},
+	}
+	return g
+}
+
+// groupEntrySchema returns the schema of a single entry in groups
+func groupEntrySchema(g *Generator, allowMapEntries bool) *Schema {
+	name := &Schema{Type: "string", Enum: append([]string(nil), mode.ToolGroups...)}
+	options := g.Generate(reflect.TypeOf(mode.GroupOptions{}))
+
+	entry := &Schema{
+		Description: "A tool group name, or a group name with options",
+		AnyOf: []*Schema{
+			name,
+			{
+				Type:     "array",
+				Items:    []*Schema{name, options},
+				MinItems: intPtr(2),
+				MaxItems: intPtr(2),
+			},
+		},
+	}
+
+	if allowMapEntries {
+		properties := make(map[string]*Schema, len(mode.ToolGroups))
+		for _, group := range mode.ToolGroups {
+			properties[group] = options
+		}
+		entry.AnyOf = append(entry.AnyOf, &Schema{
+			Type:          "object",
+			Properties:    properties,
+			PropertyNames: name,
+			MinProperties: intPtr(1),
+			MaxProperties: intPtr(1),
+		})
+	}
+
+	return entry
+}
@@ -0,0 +1,153 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Draft is the JSON Schema dialect of generated schemas
+// draft-07 is the newest dialect fully supported by yaml-language-server
+const Draft = "http://json-schema.org/draft-07/schema#"
+
+// Schema is a JSON Schema document or subschema
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	PropertyNames        *Schema            `json:"propertyNames,omitempty"`
+	MinProperties        *int               `json:"minProperties,omitempty"`
+	MaxProperties        *int               `json:"maxProperties,omitempty"`
+	Items                interface{}        `json:"items,omitempty"` // *Schema, or []*Schema for tuples
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	If                   *Schema            `json:"if,omitempty"`
+	Then                 *Schema            `json:"then,omitempty"`
+	Else                 *Schema            `json:"else,omitempty"`
+	Const                interface{}        `json:"const,omitempty"`
+}
+
+// Generator builds schemas from Go types by reflection
+//
+// Property names are read from the struct tag named by TagName ("yaml" or "json").
+// Constraints are read from the "jsonschema" tag as a comma separated list of
+// "required", "minLength=N", "enum=a|b", "patternRef=NAME" and "pattern=REGEX", and
+// descriptions from the "jsonschema_description" tag. pattern takes the rest of the tag,
+// commas included, so it must come last.
+type Generator struct {
+	TagName  string
+	Types    map[reflect.Type]func() *Schema // Schemas for types that can't be derived by reflection
+	Patterns map[string]string               // Patterns used with patternRef, shared with the code that validates them
+}
+
+// Generate returns the schema of t
+func (g *Generator) Generate(t reflect.Type) *Schema {
+	if override, ok := g.Types[t]; ok {
+		return override()
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.Generate(t.Elem())
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.Generate(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.Generate(t.Elem())}
+	case reflect.Struct:
+		return g.generateStruct(t)
+	}
+
+	// Interfaces and other kinds accept any value
+	return &Schema{}
+}
+
+// generateStruct returns an object schema with a property for each tagged field
+func (g *Generator) generateStruct(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get(g.TagName), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := g.Generate(field.Type)
+		if description := field.Tag.Get("jsonschema_description"); description != "" {
+			prop.Description = description
+		}
+
+		for _, constraint := range parseConstraints(field.Tag.Get("jsonschema")) {
+			key, value := constraint[0], constraint[1]
+			switch key {
+			case "required":
+				s.Required = append(s.Required, name)
+			case "minLength":
+				if n, err := strconv.Atoi(value); err == nil {
+					prop.MinLength = &n
+				}
+			case "pattern":
+				prop.Pattern = value
+			case "patternRef":
+				pattern, ok := g.Patterns[value]
+				if !ok {
+					panic(fmt.Sprintf("schema: unknown pattern %q in the jsonschema tag of %s.%s", value, t.Name(), field.Name))
+				}
+				prop.Pattern = pattern
+			case "enum":
+				prop.Enum = strings.Split(value, "|")
+			}
+		}
+
+		s.Properties[name] = prop
+	}
+
+	return s
+}
+
+// parseConstraints splits a jsonschema tag into key and value pairs
+// The value of pattern is the rest of the tag, since regular expressions may contain commas
+func parseConstraints(tag string) [][2]string {
+	var constraints [][2]string
+	for tag != "" {
+		constraint, rest, _ := strings.Cut(tag, ",")
+		key, value, _ := strings.Cut(constraint, "=")
+		if key == "pattern" {
+			value, rest = strings.TrimPrefix(tag, "pattern="), ""
+		}
+		constraints = append(constraints, [2]string{key, value})
+		tag = rest
+	}
+	return constraints
+}
+
+// intPtr returns a pointer to n
+func intPtr(n int) *int {
+	return &n
+}
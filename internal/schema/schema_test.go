@@ -0,0 +1,137 @@
+package schema
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/upamune/roomode/internal/mode"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+func TestGeneratorGenerate(t *testing.T) {
+	type nested struct {
+		Enabled bool `json:"enabled"`
+	}
+	type example struct {
+		Name     string            `json:"name" jsonschema:"required,minLength=1" jsonschema_description:"Name"`
+		Kind     string            `json:"kind,omitempty" jsonschema:"enum=a|b"`
+		Path     string            `json:"path" jsonschema:"required,pattern=^/"`
+		Code     string            `json:"code" jsonschema:"minLength=1,pattern=^[a-z]{1,3}(,[a-z]{1,3})*$"`
+		Slug     string            `json:"slug" jsonschema:"patternRef=slug"`
+		Count    int               `json:"count"`
+		Ratio    float64           `json:"ratio"`
+		Tags     []string          `json:"tags"`
+		Labels   map[string]string `json:"labels"`
+		Nested   *nested           `json:"nested"`
+		Any      interface{}       `json:"any"`
+		Untagged string
+		Skipped  string `json:"-"`
+		private  string
+	}
+
+	g := &Generator{TagName: "json", Patterns: map[string]string{"slug": "^[a-z]+$"}}
+	got := g.Generate(reflect.TypeOf(example{}))
+	want := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"name":   {Type: "string", Description: "Name", MinLength: intPtr(1)},
+			"kind":   {Type: "string", Enum: []string{"a", "b"}},
+			"path":   {Type: "string", Pattern: "^/"},
+			"code":   {Type: "string", MinLength: intPtr(1), Pattern: "^[a-z]{1,3}(,[a-z]{1,3})*$"},
+			"slug":   {Type: "string", Pattern: "^[a-z]+$"},
+			"count":  {Type: "integer"},
+			"ratio":  {Type: "number"},
+			"tags":   {Type: "array", Items: &Schema{Type: "string"}},
+			"labels": {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+			"nested": {Type: "object", Properties: map[string]*Schema{
+				"enabled": {Type: "boolean"},
+			}},
+			"any":      {},
+			"Untagged": {Type: "string"},
+		},
+		Required: []string{"name", "path"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		gotJSON, _ := json.MarshalIndent(got, "", "  ")
+		wantJSON, _ := json.MarshalIndent(want, "", "  ")
+		t.Errorf("Generate() =\n%s\nwant\n%s", gotJSON, wantJSON)
+	}
+}
+
+func TestGeneratorUnknownPatternRef(t *testing.T) {
+	type example struct {
+		Slug string `json:"slug" jsonschema:"patternRef=missing"`
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Generate() did not panic for an unknown patternRef")
+		}
+	}()
+	(&Generator{TagName: "json"}).Generate(reflect.TypeOf(example{}))
+}
+
+func TestFrontmatterGolden(t *testing.T) {
+	testGolden(t, Frontmatter(), "frontmatter.golden.json")
+}
+
+func TestGenerateRoomodesGolden(t *testing.T) {
+	type customMode struct {
+		Slug   string            `json:"slug" jsonschema:"required,patternRef=slug"`
+		Groups []mode.GroupEntry `json:"groups" jsonschema:"required"`
+	}
+	type roomodesFile struct {
+		CustomModes []customMode `json:"customModes" jsonschema:"required"`
+	}
+
+	testGolden(t, Generate(roomodesFile{}, "json", "RooCode .roomodes file"), "roomodes.golden.json")
+}
+
+// testGolden compares the JSON encoding of s with a golden file in testdata
+func testGolden(t *testing.T, s *Schema, golden string) {
+	t.Helper()
+
+	got, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", golden)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("schema =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFrontmatterKnownKeys(t *testing.T) {
+	// Every known frontmatter key must be described by the schema, or strict files that use
+	// it would be rejected by editors
+	s := Frontmatter()
+	for _, key := range mode.KnownKeys() {
+		if _, ok := s.Properties[key]; !ok {
+			t.Errorf("frontmatter schema has no property %q", key)
+		}
+	}
+
+	required := append([]string(nil), s.Required...)
+	sort.Strings(required)
+	if want := []string{"name"}; !reflect.DeepEqual(required, want) {
+		t.Errorf("Required = %v, want %v", required, want)
+	}
+}